@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/concourse/semver-resource/version"
+)
+
+// analyze classifies the Conventional Commits in a repository since its last
+// version tag and writes the bump they call for to a `bump` file, for use as
+// the `bump_file` of a put.
+//
+// The last version is the most recent tag reachable from HEAD that starts
+// with $TAG_PREFIX (default "v"), unless $SINCE names another revision.
+func main() {
+	if len(os.Args) < 3 {
+		println("usage: " + os.Args[0] + " <repository> <output>")
+		os.Exit(1)
+	}
+
+	repository := os.Args[1]
+	output := os.Args[2]
+
+	since := os.Getenv("SINCE")
+	if since == "" {
+		prefix, found := os.LookupEnv("TAG_PREFIX")
+		if !found {
+			prefix = "v"
+		}
+
+		tag, err := lastTag(repository, prefix)
+		if err != nil {
+			fatal("finding last version tag", err)
+		}
+
+		since = tag
+	}
+
+	messages, err := commitMessages(repository, since)
+	if err != nil {
+		fatal("reading commits", err)
+	}
+
+	for _, message := range messages {
+		subject := strings.SplitN(strings.TrimSpace(message), "\n", 2)[0]
+		fmt.Fprintf(os.Stderr, "%-5s  %s\n", version.ClassifyCommit(message), subject)
+	}
+
+	bump := version.ClassifyCommits(messages)
+
+	if since == "" {
+		fmt.Fprintf(os.Stderr, "\n%d commits in total: %s\n", len(messages), bump)
+	} else {
+		fmt.Fprintf(os.Stderr, "\n%d commits since %s: %s\n", len(messages), since, bump)
+	}
+
+	err = os.MkdirAll(output, 0755)
+	if err != nil {
+		fatal("creating output directory", err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(output, "bump"), []byte(bump+"\n"), 0644)
+	if err != nil {
+		fatal("writing bump file", err)
+	}
+}
+
+// lastTag returns the most recent tag reachable from HEAD with the given
+// prefix, or an empty string if there is none.
+func lastTag(repository string, prefix string) (string, error) {
+	describe := exec.Command("git", "describe", "--tags", "--abbrev=0", "--match", prefix+"*", "HEAD")
+	describe.Dir = repository
+
+	stderr := new(bytes.Buffer)
+	describe.Stderr = stderr
+
+	tag, err := describe.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "No names found") || strings.Contains(stderr.String(), "No tags can describe") {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("%s: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(string(tag)), nil
+}
+
+// commitMessages returns the messages of the commits reachable from HEAD but
+// not from since, or of all commits if since is empty.
+func commitMessages(repository string, since string) ([]string, error) {
+	revisions := "HEAD"
+	if since != "" {
+		revisions = since + "..HEAD"
+	}
+
+	log := exec.Command("git", "log", "-z", "--format=%B", revisions)
+	log.Dir = repository
+	log.Stderr = os.Stderr
+
+	output, err := log.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []string{}
+	for _, message := range strings.Split(string(output), "\x00") {
+		if strings.TrimSpace(message) != "" {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+func fatal(doing string, err error) {
+	println("error " + doing + ": " + err.Error())
+	os.Exit(1)
+}
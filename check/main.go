@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/concourse/semver-resource/driver"
+	"github.com/concourse/semver-resource/metrics"
 	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/tracing"
 )
 
 func main() {
@@ -17,6 +20,19 @@ func main() {
 		fatal("reading request", err)
 	}
 
+	requestSource = &request.Source
+
+	request.Source, err = driver.Namespaced(request.Source)
+	if err != nil {
+		fatal("resolving namespace", err)
+	}
+
+	recorder := metrics.FromSource(request.Source, driver.HTTPClient)
+
+	tracing.Configure(request.Source.Tracing, driver.HTTPClient)
+
+	span := tracing.Start("check")
+
 	driver, err := driver.FromSource(request.Source)
 	if err != nil {
 		fatal("constructing driver", err)
@@ -32,11 +48,27 @@ func main() {
 		}
 	}
 
+	start := time.Now()
+
 	versions, err := driver.Check(cursor)
 	if err != nil {
 		fatal("checking for new versions", err)
 	}
 
+	recorder.Since("check_duration", "Time taken to check for new versions.", start)
+
+	err = recorder.Push()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	span.End(nil)
+
+	err = tracing.Flush()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
 	delta := models.CheckResponse{}
 	for _, v := range versions {
 		delta = append(delta, models.Version{
@@ -47,7 +79,21 @@ func main() {
 	json.NewEncoder(os.Stdout).Encode(delta)
 }
 
+// requestSource is the source failures are reported with, once the request
+// has been read.
+var requestSource *models.Source
+
+// fatal reports err, both as text and as a JSON failure on stdout for
+// tooling to branch on, and exits.
 func fatal(doing string, err error) {
-	println("error " + doing + ": " + err.Error())
+	tracing.Fail(err)
+
+	message := "error " + doing + ": " + err.Error()
+	println(message)
+
+	json.NewEncoder(os.Stdout).Encode(map[string]driver.Failure{
+		"error": driver.NewFailure("check", requestSource, message, err),
+	})
+
 	os.Exit(1)
 }
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/blang/semver"
+
+	"github.com/concourse/semver-resource/driver"
+	"github.com/concourse/semver-resource/models"
+)
+
+// Sources are the two configurations to compare, e.g. the store being
+// migrated from and the one being migrated to.
+type Sources struct {
+	Left  models.Source `json:"left"`
+	Right models.Source `json:"right"`
+}
+
+// side is what one source stores.
+type side struct {
+	name     string
+	location string
+	version  semver.Version
+
+	// stored is false if the version reported is the initial one, because
+	// none is stored. It is assumed to be stored if the driver cannot tell.
+	stored bool
+
+	history []driver.AuditRecord
+}
+
+// diff compares the versions, and audit logs, stored by the left and right
+// sources given on stdin, printing how they differ. Like diff(1), it exits 1
+// if they differ and 2 if they could not be compared.
+func main() {
+	if len(os.Args) > 1 {
+		println("usage: " + os.Args[0] + " < sources.json")
+		os.Exit(2)
+	}
+
+	var sources Sources
+	err := json.NewDecoder(os.Stdin).Decode(&sources)
+	if err != nil {
+		fatal("reading sources", err)
+	}
+
+	if (sources.Left.AuditLog == "") != (sources.Right.AuditLog == "") {
+		fatal("comparing history", fmt.Errorf("audit_log must be set for both sources, or neither"))
+	}
+
+	left, right, err := readSides(sources)
+	if err != nil {
+		fatal("reading", err)
+	}
+
+	fmt.Printf("left:  %s\n", left.describe())
+	fmt.Printf("right: %s\n", right.describe())
+
+	diverged := false
+
+	if left.stored != right.stored || !left.version.Equals(right.version) {
+		diverged = true
+		fmt.Printf("\nversions differ: %s != %s\n", left.summary(), right.summary())
+	}
+
+	if sources.Left.AuditLog != "" {
+		common, leftOnly, rightOnly := driver.CompareHistories(left.history, right.history)
+
+		if len(leftOnly) > 0 || len(rightOnly) > 0 {
+			diverged = true
+			fmt.Printf("\nhistories diverge after %d common changes:\n", common)
+
+			for _, record := range leftOnly {
+				fmt.Printf("< %s\n", describeChange(record))
+			}
+
+			for _, record := range rightOnly {
+				fmt.Printf("> %s\n", describeChange(record))
+			}
+		}
+	}
+
+	if diverged {
+		os.Exit(1)
+	}
+
+	fmt.Println("\nno differences")
+}
+
+// readSides reads both sources in a temporary directory, which holds a clone
+// for each side, as they may be different repositories, and stands in for
+// the home directory, so that the git driver's credentials and config do not
+// replace the user's.
+func readSides(sources Sources) (side, side, error) {
+	work, err := ioutil.TempDir("", "semver-diff")
+	if err != nil {
+		return side{}, side{}, err
+	}
+
+	defer os.RemoveAll(work)
+
+	err = driver.UseHome(work)
+	if err != nil {
+		return side{}, side{}, err
+	}
+
+	left, err := read("left", sources.Left, filepath.Join(work, "left"))
+	if err != nil {
+		return side{}, side{}, fmt.Errorf("left: %w", err)
+	}
+
+	right, err := read("right", sources.Right, filepath.Join(work, "right"))
+	if err != nil {
+		return side{}, side{}, fmt.Errorf("right: %w", err)
+	}
+
+	return left, right, nil
+}
+
+func read(name string, source models.Source, repoDir string) (side, error) {
+	source, err := driver.Namespaced(source)
+	if err != nil {
+		return side{}, err
+	}
+
+	store, err := driver.FromSource(source)
+	if err != nil {
+		return side{}, err
+	}
+
+	if git, ok := store.(*driver.GitDriver); ok {
+		git.RepoDir = repoDir
+	}
+
+	versions, err := store.Check(nil)
+	if err != nil {
+		return side{}, err
+	}
+
+	result := side{
+		name:     name,
+		location: driver.Location(source),
+		version:  versions[len(versions)-1],
+		stored:   true,
+	}
+
+	if reader, ok := store.(driver.LastModifiedReader); ok {
+		_, result.stored, err = reader.LastModified()
+		if err != nil {
+			return side{}, err
+		}
+	}
+
+	if source.AuditLog != "" {
+		result.history, err = driver.History(store, source.AuditLog)
+		if err != nil {
+			return side{}, err
+		}
+	}
+
+	return result, nil
+}
+
+func (side side) summary() string {
+	if !side.stored {
+		return "none stored"
+	}
+
+	return side.version.String()
+}
+
+func (side side) describe() string {
+	description := side.location + " " + side.summary()
+	if side.history != nil {
+		description += fmt.Sprintf(" (%d changes)", len(side.history))
+	}
+
+	return description
+}
+
+func describeChange(record driver.AuditRecord) string {
+	from := record.From
+	if from == "" {
+		from = "none"
+	}
+
+	return fmt.Sprintf("%s -> %s (%s) at %s", from, record.To, record.Bump, record.Timestamp.Format("2006-01-02 15:04:05 MST"))
+}
+
+func fatal(doing string, err error) {
+	println("error " + doing + ": " + err.Error())
+	os.Exit(2)
+}
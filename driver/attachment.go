@@ -0,0 +1,130 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/signing"
+)
+
+// Change describes a version being written by a driver.
+type Change struct {
+	// From is the version being replaced, or nil if none was stored.
+	From *semver.Version
+	To   semver.Version
+
+	// Bump describes how To was derived, or is "set" for an explicit version.
+	Bump string
+
+	// Modified is when From was written, if known. It is only filled in for
+	// guards.
+	Modified *time.Time
+}
+
+// An Attachment is content stored next to the version, e.g. another file in
+// the repository or another object in the bucket, which is rendered afresh
+// from its previous content with every change. The git driver commits
+// attachments together with the version; the s3 and swift drivers write them
+// right after it.
+type Attachment struct {
+	Name   string
+	Render func(previous []byte, change Change) ([]byte, error)
+}
+
+// AttachmentReader is implemented by drivers that can read back the current
+// content of an attachment. Missing attachments read as nil.
+type AttachmentReader interface {
+	ReadAttachment(name string) ([]byte, error)
+}
+
+func attachmentsFor(source models.Source) ([]Attachment, error) {
+	attachments := []Attachment{}
+
+	if source.AuditLog != "" {
+		attachments = append(attachments, AuditLog(source.AuditLog))
+	}
+
+	signer, err := signing.FromOptions(source.Signing, HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if signer != nil {
+		name := source.Signing.Name
+		if name == "" {
+			name = VersionName(source)
+		}
+
+		attachments = append(attachments, Signatures(name, signer)...)
+	}
+
+	return attachments, nil
+}
+
+func changeFrom(current semver.Version, exists bool, to semver.Version, bump string) Change {
+	change := Change{To: to, Bump: bump}
+	if exists {
+		change.From = &current
+	}
+
+	return change
+}
+
+const setBump = "set"
+
+// invalidVersion is returned when a stored version cannot be read, e.g.
+// because the file was edited by hand.
+type invalidVersion struct {
+	error
+}
+
+// setChange is the change setting to makes to the version read, which is
+// treated as missing if it is invalid, so that setting a version repairs it.
+// Only attachments and guards care about the version being replaced, so
+// without them it is not read at all.
+func setChange(attachments []Attachment, guards []Guard, read func() (semver.Version, bool, error), to semver.Version) (Change, error) {
+	if len(attachments) == 0 && len(guards) == 0 {
+		return Change{To: to, Bump: setBump}, nil
+	}
+
+	current, exists, err := read()
+
+	var invalid invalidVersion
+	if errors.As(err, &invalid) {
+		return Change{To: to, Bump: setBump}, nil
+	}
+
+	if err != nil {
+		return Change{}, err
+	}
+
+	return changeFrom(current, exists, to, setBump), nil
+}
+
+// VersionName is the name of the file, key or item the version is stored in.
+func VersionName(source models.Source) string {
+	switch source.Driver {
+	case models.DriverGit:
+		return source.File
+	case models.DriverSwift:
+		return source.OpenStack.ItemName
+	}
+
+	return source.Key
+}
+
+// Location is a URI identifying where the version is stored, e.g.
+// s3://bucket/key.
+func Location(source models.Source) string {
+	switch source.Driver {
+	case models.DriverGit:
+		return fmt.Sprintf("git+%s@refs/heads/%s#%s", source.URI, source.Branch, source.File)
+	case models.DriverSwift:
+		return fmt.Sprintf("swift://%s/%s", source.OpenStack.Container, source.OpenStack.ItemName)
+	}
+
+	return fmt.Sprintf("s3://%s/%s", source.Bucket, source.Key)
+}
@@ -0,0 +1,108 @@
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// AuditRecord is one line of an audit log.
+type AuditRecord struct {
+	From      string               `json:"from,omitempty"`
+	To        string               `json:"to"`
+	Bump      string               `json:"bump"`
+	Build     models.BuildMetadata `json:"build"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+// AuditLog is an attachment appending a JSON record of every change, one per
+// line, to name. Existing lines are never changed, but as the s3 and swift
+// drivers rewrite the whole log unconditionally, a record written at the same
+// time by another put can be lost with them.
+func AuditLog(name string) Attachment {
+	return Attachment{
+		Name: name,
+		Render: func(previous []byte, change Change) ([]byte, error) {
+			record := AuditRecord{
+				To:        change.To.String(),
+				Bump:      change.Bump,
+				Build:     models.BuildMetadataFromEnv(),
+				Timestamp: time.Now().UTC(),
+			}
+
+			if change.From != nil {
+				record.From = change.From.String()
+			}
+
+			line, err := json.Marshal(record)
+			if err != nil {
+				return nil, err
+			}
+
+			log := append([]byte{}, previous...)
+			if len(log) > 0 && log[len(log)-1] != '\n' {
+				log = append(log, '\n')
+			}
+
+			return append(append(log, line...), '\n'), nil
+		},
+	}
+}
+
+// History reads the audit log kept by driver under name, oldest first.
+func History(driver Driver, name string) ([]AuditRecord, error) {
+	reader, ok := driver.(AttachmentReader)
+	if !ok {
+		return nil, fmt.Errorf("driver cannot read an audit log")
+	}
+
+	log, err := reader.ReadAttachment(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseAuditLog(log)
+}
+
+func ParseAuditLog(log []byte) ([]AuditRecord, error) {
+	records := []AuditRecord{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(log))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var record AuditRecord
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return nil, fmt.Errorf("parsing audit log: %s", err)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
+
+// CompareHistories returns how many changes two audit logs share from their
+// start, and the changes each has after that. Changes are the same if they
+// are between the same versions by the same bump, whenever and by whichever
+// build they were made.
+func CompareHistories(left []AuditRecord, right []AuditRecord) (int, []AuditRecord, []AuditRecord) {
+	common := 0
+	for common < len(left) && common < len(right) {
+		l, r := left[common], right[common]
+		if l.From != r.From || l.To != r.To || l.Bump != r.Bump {
+			break
+		}
+
+		common++
+	}
+
+	return common, left[common:], right[common:]
+}
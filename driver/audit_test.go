@@ -0,0 +1,115 @@
+package driver_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/driver"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuditLog", func() {
+	var attachment driver.Attachment
+
+	BeforeEach(func() {
+		os.Setenv("BUILD_PIPELINE_NAME", "some-pipeline")
+		attachment = driver.AuditLog("version.audit")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("BUILD_PIPELINE_NAME")
+	})
+
+	It("appends a record of each change", func() {
+		from := semver.Version{Major: 1, Minor: 4, Patch: 2}
+
+		log, err := attachment.Render(nil, driver.Change{
+			To:   from,
+			Bump: "set",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		log, err = attachment.Render(log, driver.Change{
+			From: &from,
+			To:   semver.Version{Major: 1, Minor: 5},
+			Bump: "minor",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		records, err := driver.ParseAuditLog(log)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+
+		Expect(records[0].From).To(BeEmpty())
+		Expect(records[0].To).To(Equal("1.4.2"))
+
+		Expect(records[1].From).To(Equal("1.4.2"))
+		Expect(records[1].To).To(Equal("1.5.0"))
+		Expect(records[1].Bump).To(Equal("minor"))
+		Expect(records[1].Build.Pipeline).To(Equal("some-pipeline"))
+		Expect(records[1].Timestamp.IsZero()).To(BeFalse())
+	})
+
+	It("keeps existing records intact", func() {
+		log, err := attachment.Render([]byte(`{"to":"1.0.0","bump":"set"}`), driver.Change{
+			To:   semver.Version{Major: 2},
+			Bump: "major",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		records, err := driver.ParseAuditLog(log)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].To).To(Equal("1.0.0"))
+	})
+})
+
+var _ = Describe("CompareHistories", func() {
+	history := func(changes ...string) []driver.AuditRecord {
+		records := []driver.AuditRecord{}
+		from := ""
+		for _, to := range changes {
+			records = append(records, driver.AuditRecord{From: from, To: to, Bump: "patch"})
+			from = to
+		}
+
+		return records
+	}
+
+	It("reports identical histories as entirely common", func() {
+		common, leftOnly, rightOnly := driver.CompareHistories(history("1.0.1", "1.0.2"), history("1.0.1", "1.0.2"))
+		Expect(common).To(Equal(2))
+		Expect(leftOnly).To(BeEmpty())
+		Expect(rightOnly).To(BeEmpty())
+	})
+
+	It("ignores when and by which build changes were made", func() {
+		left := history("1.0.1")
+		left[0].Build.Pipeline = "old-pipeline"
+		left[0].Timestamp = time.Now()
+
+		common, _, _ := driver.CompareHistories(left, history("1.0.1"))
+		Expect(common).To(Equal(1))
+	})
+
+	It("reports the changes one side is behind by", func() {
+		common, leftOnly, rightOnly := driver.CompareHistories(history("1.0.1", "1.0.2", "1.0.3"), history("1.0.1"))
+		Expect(common).To(Equal(1))
+		Expect(leftOnly).To(Equal(history("1.0.1", "1.0.2", "1.0.3")[1:]))
+		Expect(rightOnly).To(BeEmpty())
+	})
+
+	It("reports the changes each side made after diverging", func() {
+		left := history("1.0.1", "1.0.2")
+		right := history("1.0.1", "1.1.0")
+		right[1].Bump = "minor"
+
+		common, leftOnly, rightOnly := driver.CompareHistories(left, right)
+		Expect(common).To(Equal(1))
+		Expect(leftOnly).To(Equal(left[1:]))
+		Expect(rightOnly).To(Equal(right[1:]))
+	})
+})
@@ -0,0 +1,92 @@
+package driver
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// DefaultChangelogTemplate renders a Markdown heading for the new version,
+// followed by the notes if there are any.
+const DefaultChangelogTemplate = `## {{.Version}} ({{.Date}})
+{{if .Notes}}
+{{.Notes}}
+{{end}}`
+
+// ChangelogEntry is what a changelog template is executed against.
+type ChangelogEntry struct {
+	Version  string
+	Previous string
+	Bump     string
+	Date     string
+	Notes    string
+	Build    models.BuildMetadata
+}
+
+// Changelog is an attachment prepending an entry for every change to a
+// changelog, below its title if it starts with one. The entry is rendered
+// from template, or DefaultChangelogTemplate if that is empty.
+func Changelog(name string, tmpl string, notes string) (Attachment, error) {
+	if tmpl == "" {
+		tmpl = DefaultChangelogTemplate
+	}
+
+	entryTemplate, err := template.New(name).Parse(tmpl)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("parsing changelog template: %s", err)
+	}
+
+	return Attachment{
+		Name: name,
+		Render: func(previous []byte, change Change) ([]byte, error) {
+			entry := ChangelogEntry{
+				Version: change.To.String(),
+				Bump:    change.Bump,
+				Date:    time.Now().UTC().Format("2006-01-02"),
+				Notes:   strings.TrimSpace(notes),
+				Build:   models.BuildMetadataFromEnv(),
+			}
+
+			if change.From != nil {
+				entry.Previous = change.From.String()
+			}
+
+			rendered := new(bytes.Buffer)
+
+			err := entryTemplate.Execute(rendered, entry)
+			if err != nil {
+				return nil, fmt.Errorf("rendering changelog entry: %s", err)
+			}
+
+			return prependEntry(previous, rendered.Bytes()), nil
+		},
+	}, nil
+}
+
+func prependEntry(changelog []byte, entry []byte) []byte {
+	entry = append(bytes.TrimRight(entry, "\n"), '\n')
+
+	var title []byte
+	if bytes.HasPrefix(changelog, []byte("# ")) {
+		end := bytes.IndexByte(changelog, '\n')
+		if end == -1 {
+			end = len(changelog)
+		}
+
+		title = append(changelog[:end:end], '\n', '\n')
+		changelog = changelog[end:]
+	}
+
+	changelog = bytes.TrimLeft(changelog, "\n")
+
+	result := append(title, entry...)
+	if len(changelog) > 0 {
+		result = append(append(result, '\n'), changelog...)
+	}
+
+	return result
+}
@@ -0,0 +1,64 @@
+package driver_test
+
+import (
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/driver"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Changelog", func() {
+	var from semver.Version
+	var change driver.Change
+	var today string
+
+	BeforeEach(func() {
+		from = semver.Version{Major: 1, Minor: 4, Patch: 2}
+		change = driver.Change{
+			From: &from,
+			To:   semver.Version{Major: 1, Minor: 5},
+			Bump: "minor",
+		}
+
+		today = time.Now().UTC().Format("2006-01-02")
+	})
+
+	render := func(tmpl string, notes string, previous string) string {
+		attachment, err := driver.Changelog("CHANGELOG.md", tmpl, notes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(attachment.Name).To(Equal("CHANGELOG.md"))
+
+		changelog, err := attachment.Render([]byte(previous), change)
+		Expect(err).NotTo(HaveOccurred())
+
+		return string(changelog)
+	}
+
+	It("starts a changelog", func() {
+		Expect(render("", "", "")).To(Equal("## 1.5.0 (" + today + ")\n"))
+	})
+
+	It("includes the notes", func() {
+		Expect(render("", "Added widgets.\n\n", "")).To(Equal("## 1.5.0 (" + today + ")\n\nAdded widgets.\n"))
+	})
+
+	It("prepends to existing entries", func() {
+		Expect(render("", "", "## 1.4.2\n\nFixed widgets.\n")).To(Equal("## 1.5.0 (" + today + ")\n\n## 1.4.2\n\nFixed widgets.\n"))
+	})
+
+	It("keeps the title at the top", func() {
+		Expect(render("", "", "# Changelog\n\n## 1.4.2\n")).To(Equal("# Changelog\n\n## 1.5.0 (" + today + ")\n\n## 1.4.2\n"))
+	})
+
+	It("renders the given template", func() {
+		Expect(render("v{{.Version}} (was {{.Previous}}, {{.Bump}}): {{.Notes}}", "Added widgets.", "")).To(Equal("v1.5.0 (was 1.4.2, minor): Added widgets.\n"))
+	})
+
+	It("rejects malformed templates", func() {
+		_, err := driver.Changelog("CHANGELOG.md", "{{.Version", "")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,641 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/version"
+	"github.com/nu7hatch/gouuid"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+// The conformance specs run against real backends, such as the disposable
+// ones started by scripts/integration. Each driver is skipped unless its
+// backend is configured through the environment.
+
+var _ = Describe("Conformance", func() {
+	var originalRepoDir string
+	var originalNetRcPath string
+	var restoreEnv []func()
+	var home string
+
+	BeforeEach(func() {
+		originalRepoDir = gitRepoDir
+		originalNetRcPath = netRcPath
+
+		// parallel nodes must not share a clone of the repository
+		gitRepoDir = filepath.Join(os.TempDir(), fmt.Sprintf("semver-git-repo-%d", config.GinkgoConfig.ParallelNode))
+
+		// the git driver configures git globally and replaces ~/.netrc, which
+		// must not touch those of whoever runs the specs. Go's build cache,
+		// which is found through HOME, stays where it is.
+		cache, err := os.UserCacheDir()
+		Expect(err).NotTo(HaveOccurred())
+
+		home, err = ioutil.TempDir("", "semver-conformance-home")
+		Expect(err).NotTo(HaveOccurred())
+
+		restoreEnv = []func(){}
+		if os.Getenv("GOCACHE") == "" {
+			restoreEnv = append(restoreEnv, setenv("GOCACHE", filepath.Join(cache, "go-build")))
+		}
+
+		restoreEnv = append(restoreEnv,
+			setenv("HOME", home),
+			setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig")),
+		)
+
+		netRcPath = filepath.Join(home, netRcFileName)
+	})
+
+	AfterEach(func() {
+		gitRepoDir = originalRepoDir
+		netRcPath = originalNetRcPath
+
+		for _, restore := range restoreEnv {
+			restore()
+		}
+
+		os.RemoveAll(home)
+	})
+
+	describeConformance("git", gitConformanceSource)
+	describeConformance("s3", s3ConformanceSource)
+	describeConformance("swift", swiftConformanceSource)
+
+	Describe("git concurrent bumps", func() {
+		var outPath string
+
+		BeforeEach(func() {
+			if os.Getenv("SEMVER_TESTING_GIT_URI") == "" {
+				Skip("$SEMVER_TESTING_GIT_URI not set, skipping git conformance")
+			}
+
+			outPath = buildOut(home)
+		})
+
+		It("applies every bump exactly once", func() {
+			source, _ := gitConformanceSource(newConformanceID())
+			source.InitialVersion = "1.2.3"
+
+			const bumpers = 5
+
+			sessions := []*gexec.Session{}
+			for i := 0; i < bumpers; i++ {
+				sandbox, err := ioutil.TempDir("", "concurrent-bump")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(sandbox)
+
+				sessions = append(sessions, startOut(outPath, sandbox, models.OutRequest{
+					Source: source,
+					Params: models.OutParams{Bump: "patch"},
+				}))
+			}
+
+			versions := []string{}
+			for _, session := range sessions {
+				Eventually(session, "60s").Should(gexec.Exit(0))
+
+				var response models.OutResponse
+				err := json.Unmarshal(session.Out.Contents(), &response)
+				Expect(err).NotTo(HaveOccurred())
+
+				versions = append(versions, response.Version.Number)
+			}
+
+			Expect(versions).To(ConsistOf("1.2.4", "1.2.5", "1.2.6", "1.2.7", "1.2.8"))
+
+			driver, err := FromSource(source)
+			Expect(err).NotTo(HaveOccurred())
+
+			current, err := driver.Check(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionStrings(current)).To(Equal([]string{"1.2.8"}))
+		})
+	})
+
+	Describe("git commits on cooldown", func() {
+		var outPath string
+		var source models.Source
+		var driver *GitDriver
+		var sandbox string
+
+		BeforeEach(func() {
+			var ok bool
+			source, ok = gitConformanceSource(newConformanceID())
+			if !ok {
+				Skip("$SEMVER_TESTING_GIT_URI not set, skipping git conformance")
+			}
+
+			outPath = buildOut(home)
+
+			store, err := FromSource(source)
+			Expect(err).NotTo(HaveOccurred())
+
+			driver = store.(*GitDriver)
+			Expect(driver.Set(semver.Version{Major: 1, Minor: 2, Patch: 3})).To(Succeed())
+
+			reserved, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reserved.String()).To(Equal("1.2.4"))
+
+			sandbox, err = ioutil.TempDir("", "cooldown-commit")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.Mkdir(filepath.Join(sandbox, "version"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sandbox, "version", "number"), []byte("1.2.4"), 0644)).To(Succeed())
+
+			source.MinBumpInterval = "1h"
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(sandbox)
+		})
+
+		commit := func() *gexec.Session {
+			return startOut(outPath, sandbox, models.OutRequest{
+				Source: source,
+				Params: models.OutParams{Commit: "version/number"},
+			})
+		}
+
+		expectStillReserved := func() {
+			Expect(driver.setUpRepo()).To(Succeed())
+
+			reservations, err := driver.readReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionStrings(reservations)).To(Equal([]string{"1.2.4"}))
+		}
+
+		It("fails, leaving the version reserved", func() {
+			Eventually(commit(), "60s").Should(gexec.Exit(1))
+
+			expectStillReserved()
+		})
+
+		It("emits the current version if configured to, leaving the version reserved", func() {
+			source.CooldownBehavior = "return_existing"
+
+			session := commit()
+			Eventually(session, "60s").Should(gexec.Exit(0))
+
+			var response models.OutResponse
+			err := json.Unmarshal(session.Out.Contents(), &response)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Version.Number).To(Equal("1.2.3"))
+
+			expectStillReserved()
+		})
+	})
+
+	Describe("git reservations", func() {
+		var driver *GitDriver
+
+		BeforeEach(func() {
+			source, ok := gitConformanceSource(newConformanceID())
+			if !ok {
+				Skip("$SEMVER_TESTING_GIT_URI not set, skipping git conformance")
+			}
+
+			source.InitialVersion = "1.2.3"
+
+			store, err := FromSource(source)
+			Expect(err).NotTo(HaveOccurred())
+
+			driver = store.(*GitDriver)
+		})
+
+		It("reserves unique versions without publishing them", func() {
+			first, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(first.String()).To(Equal("1.2.4"))
+
+			second, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.String()).To(Equal("1.2.5"))
+
+			current, err := driver.Check(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionStrings(current)).To(Equal([]string{"1.2.3"}))
+		})
+
+		It("publishes committed versions unless a later one is current", func() {
+			first, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+
+			published, err := driver.Commit(second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(published).To(BeTrue())
+
+			published, err = driver.Commit(first)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(published).To(BeFalse())
+
+			current, err := driver.Check(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionStrings(current)).To(Equal([]string{"1.2.5"}))
+
+			next, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(next.String()).To(Equal("1.2.6"))
+		})
+
+		It("releases abandoned versions", func() {
+			reserved, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(driver.Abandon(reserved)).To(Succeed())
+
+			_, err = driver.Commit(reserved)
+			Expect(err).To(MatchError(NotReserved{Version: reserved}))
+
+			again, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(Equal(reserved))
+		})
+
+		It("leaves reserved versions to be committed", func() {
+			reserved, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+
+			bumped, err := driver.Bump(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bumped.String()).To(Equal("1.2.5"))
+
+			Expect(driver.Set(reserved)).To(MatchError(Reserved{Version: reserved}))
+
+			// out records the version bumped from
+			bump := &version.RecordingBump{Bump: version.BumpFromParams("patch", "rc")}
+
+			_, err = driver.Reserve(version.BumpFromParams("patch", "rc"))
+			Expect(err).NotTo(HaveOccurred())
+
+			bumped, err = driver.Bump(bump)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bumped.String()).To(Equal("1.2.6-rc.2"))
+			Expect(bump.Applied.String()).To(Equal("1.2.5"))
+
+			current, err := driver.Check(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionStrings(current)).To(Equal([]string{"1.2.6-rc.2"}))
+		})
+	})
+
+	Describe("git guards", func() {
+		var source models.Source
+		var driver *GitDriver
+		var checked []string
+
+		BeforeEach(func() {
+			var ok bool
+			source, ok = gitConformanceSource(newConformanceID())
+			if !ok {
+				Skip("$SEMVER_TESTING_GIT_URI not set, skipping git conformance")
+			}
+
+			source.InitialVersion = "1.2.3"
+
+			store, err := FromSource(source)
+			Expect(err).NotTo(HaveOccurred())
+
+			driver = store.(*GitDriver)
+
+			checked = []string{}
+			driver.Guard(func(change Change) error {
+				from := "none"
+				if change.From != nil {
+					from = change.From.String()
+				}
+
+				checked = append(checked, from+" -> "+change.To.String())
+				return nil
+			})
+		})
+
+		It("checks the change written again after losing a race", func() {
+			rivalStore, err := FromSource(source)
+			Expect(err).NotTo(HaveOccurred())
+
+			rival := rivalStore.(*GitDriver)
+			rival.RepoDir = gitRepoDir + "-rival"
+			defer os.RemoveAll(rival.RepoDir)
+
+			// another writer pushes between the first check and its write
+			driver.Guard(func(change Change) error {
+				if len(checked) == 1 {
+					Expect(rival.Set(semver.Version{Major: 2})).To(Succeed())
+				}
+
+				return nil
+			})
+
+			bumped, err := driver.Bump(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bumped.String()).To(Equal("2.0.1"))
+
+			Expect(checked).To(Equal([]string{"none -> 1.2.4", "2.0.0 -> 2.0.1"}))
+			Expect(driver.Conflicts()).To(Equal(1))
+		})
+
+		It("gives guards when the version replaced was committed, as of each attempt", func() {
+			Expect(driver.Set(semver.Version{Major: 1})).To(Succeed())
+
+			rivalStore, err := FromSource(source)
+			Expect(err).NotTo(HaveOccurred())
+
+			rival := rivalStore.(*GitDriver)
+			rival.RepoDir = gitRepoDir + "-rival"
+			defer os.RemoveAll(rival.RepoDir)
+
+			modified := []time.Time{}
+			driver.Guard(func(change Change) error {
+				Expect(change.Modified).NotTo(BeNil())
+				modified = append(modified, *change.Modified)
+
+				// commit times have a resolution of a second
+				if len(modified) == 1 {
+					time.Sleep(time.Second)
+					Expect(rival.Set(semver.Version{Major: 2})).To(Succeed())
+				}
+
+				return nil
+			})
+
+			_, err = driver.Bump(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(modified).To(HaveLen(2))
+			Expect(modified[1]).To(BeTemporally(">", modified[0]))
+		})
+
+		It("checks the version actually reserved", func() {
+			_, err := driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = driver.Reserve(version.PatchBump{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(checked).To(Equal([]string{"none -> 1.2.4", "none -> 1.2.5"}))
+		})
+
+		It("sets over a version that cannot be read", func() {
+			Expect(driver.Set(semver.Version{Major: 1})).To(Succeed())
+
+			Expect(ioutil.WriteFile(filepath.Join(gitRepoDir, source.File), []byte("garbage\n"), 0644)).To(Succeed())
+
+			gitCommit := exec.Command("git", "commit", "-am", "corrupt the version")
+			gitCommit.Dir = gitRepoDir
+			Expect(gitCommit.Run()).To(Succeed())
+
+			gitPush := exec.Command("git", "push", "origin", "HEAD:master")
+			gitPush.Dir = gitRepoDir
+			Expect(gitPush.Run()).To(Succeed())
+
+			_, err := driver.Check(nil)
+			Expect(err).To(HaveOccurred())
+
+			Expect(driver.Set(semver.Version{Major: 2})).To(Succeed())
+			Expect(checked[len(checked)-1]).To(Equal("none -> 2.0.0"))
+
+			current, err := driver.Check(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionStrings(current)).To(Equal([]string{"2.0.0"}))
+		})
+
+		It("writes nothing when a guard refuses the change", func() {
+			refused := fmt.Errorf("refused")
+			driver.Guard(func(Change) error {
+				return refused
+			})
+
+			_, err := driver.Bump(version.PatchBump{})
+			Expect(err).To(Equal(refused))
+
+			Expect(driver.Set(semver.Version{Major: 2})).To(Equal(refused))
+
+			current, err := driver.Check(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionStrings(current)).To(Equal([]string{"1.2.3"}))
+		})
+	})
+})
+
+func describeConformance(name string, sourceFor func(id string) (models.Source, bool)) {
+	Describe(name, func() {
+		var driver Driver
+		var auditLog string
+
+		BeforeEach(func() {
+			id := newConformanceID()
+
+			source, ok := sourceFor(id)
+			if !ok {
+				Skip(name + " backend not configured, skipping conformance")
+			}
+
+			source.InitialVersion = "1.2.3"
+			source.AuditLog = id + ".audit"
+			auditLog = source.AuditLog
+
+			var err error
+			driver, err = FromSource(source)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reports the initial version when none is stored", func() {
+			versions, err := driver.Check(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionStrings(versions)).To(Equal([]string{"1.2.3"}))
+		})
+
+		It("bumps from the initial version when none is stored", func() {
+			bumped, err := driver.Bump(version.MinorBump{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bumped.String()).To(Equal("1.3.0"))
+
+			versions, err := driver.Check(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versionStrings(versions)).To(Equal([]string{"1.3.0"}))
+		})
+
+		It("bumps the stored version", func() {
+			Expect(driver.Set(mustParse("2.0.9"))).To(Succeed())
+
+			bumped, err := driver.Bump(version.MultiBump{version.PatchBump{}, version.PreBump{Pre: "rc"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bumped.String()).To(Equal("2.0.10-rc.1"))
+		})
+
+		It("reports the stored version from an older or equal cursor", func() {
+			Expect(driver.Set(mustParse("2.0.9"))).To(Succeed())
+
+			for _, cursor := range []string{"1.0.0", "2.0.9"} {
+				from := mustParse(cursor)
+				versions, err := driver.Check(&from)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(versionStrings(versions)).To(Equal([]string{"2.0.9"}))
+			}
+		})
+
+		It("records every change in the audit log", func() {
+			Expect(driver.Set(mustParse("2.0.9"))).To(Succeed())
+
+			_, err := driver.Bump(version.MajorBump{})
+			Expect(err).NotTo(HaveOccurred())
+
+			records, err := History(driver, auditLog)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(2))
+
+			Expect(records[0].From).To(BeEmpty())
+			Expect(records[0].To).To(Equal("2.0.9"))
+			Expect(records[0].Bump).To(Equal("set"))
+
+			Expect(records[1].From).To(Equal("2.0.9"))
+			Expect(records[1].To).To(Equal("3.0.0"))
+			Expect(records[1].Bump).To(Equal("major"))
+		})
+
+		It("reports nothing from a newer cursor", func() {
+			Expect(driver.Set(mustParse("2.0.9"))).To(Succeed())
+
+			from := mustParse("3.0.0")
+			versions, err := driver.Check(&from)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(BeEmpty())
+		})
+	})
+}
+
+// buildOut builds out into dir. gexec is not used, as it cannot build again
+// once its artifacts are cleaned up.
+func buildOut(dir string) string {
+	outPath := filepath.Join(dir, "out")
+	if runtime.GOOS == "windows" {
+		outPath += ".exe"
+	}
+
+	build := exec.Command("go", "build", "-o", outPath, "github.com/concourse/semver-resource/out")
+	build.Stdout = GinkgoWriter
+	build.Stderr = GinkgoWriter
+	Expect(build.Run()).To(Succeed())
+
+	return outPath
+}
+
+// startOut runs out in sandbox with request. The process gets its own clone,
+// credentials and git config.
+func startOut(outPath string, sandbox string, request models.OutRequest) *gexec.Session {
+	outCmd := exec.Command(outPath, sandbox)
+	outCmd.Env = append(os.Environ(), "TMPDIR="+sandbox, "HOME="+sandbox, "GIT_CONFIG_GLOBAL="+filepath.Join(sandbox, ".gitconfig"))
+
+	stdin, err := outCmd.StdinPipe()
+	Expect(err).NotTo(HaveOccurred())
+
+	session, err := gexec.Start(outCmd, GinkgoWriter, GinkgoWriter)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = json.NewEncoder(stdin).Encode(request)
+	Expect(err).NotTo(HaveOccurred())
+	stdin.Close()
+
+	return session
+}
+
+func mustParse(v string) semver.Version {
+	parsed, err := semver.Parse(v)
+	Expect(err).NotTo(HaveOccurred())
+
+	return parsed
+}
+
+func versionStrings(versions []semver.Version) []string {
+	strs := []string{}
+	for _, v := range versions {
+		strs = append(strs, v.String())
+	}
+
+	return strs
+}
+
+func newConformanceID() string {
+	guid, err := uuid.NewV4()
+	Expect(err).NotTo(HaveOccurred())
+
+	return guid.String()
+}
+
+// setenv sets an environment variable, returning a function restoring it.
+func setenv(name string, value string) func() {
+	original, set := os.LookupEnv(name)
+	os.Setenv(name, value)
+
+	return func() {
+		if set {
+			os.Setenv(name, original)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func gitConformanceSource(id string) (models.Source, bool) {
+	uri := os.Getenv("SEMVER_TESTING_GIT_URI")
+
+	return models.Source{
+		Driver:  models.DriverGit,
+		URI:     uri,
+		Branch:  "master",
+		File:    id,
+		GitUser: "semver-conformance <semver@example.com>",
+	}, uri != ""
+}
+
+func s3ConformanceSource(id string) (models.Source, bool) {
+	endpoint := os.Getenv("SEMVER_TESTING_S3_ENDPOINT")
+
+	return models.Source{
+		Driver:          models.DriverS3,
+		Bucket:          os.Getenv("SEMVER_TESTING_S3_BUCKET"),
+		Key:             id,
+		AccessKeyID:     os.Getenv("SEMVER_TESTING_S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("SEMVER_TESTING_S3_SECRET_ACCESS_KEY"),
+		Endpoint:        endpoint,
+		DisableSSL:      os.Getenv("SEMVER_TESTING_S3_DISABLE_SSL") == "true",
+	}, endpoint != ""
+}
+
+func swiftConformanceSource(id string) (models.Source, bool) {
+	if client == nil {
+		return models.Source{}, false
+	}
+
+	return models.Source{
+		Driver: models.DriverSwift,
+		OpenStack: models.OpenStackOptions{
+			Container:        containerName,
+			ItemName:         id,
+			Region:           os.Getenv("OS_REGION_NAME"),
+			IdentityEndpoint: os.Getenv("OS_AUTH_URL"),
+			Username:         os.Getenv("OS_USERNAME"),
+			Password:         os.Getenv("OS_PASSWORD"),
+			TenantID:         os.Getenv("OS_TENANT_ID"),
+			TenantName:       os.Getenv("OS_TENANT_NAME"),
+		},
+	}, true
+}
@@ -0,0 +1,78 @@
+package driver
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// ResolveCredentials fills in the driver's credentials from wherever
+// source.CredentialsFrom says they are kept. Credentials are fetched afresh
+// every time, so short-lived ones are never reused.
+func ResolveCredentials(source models.Source) (models.Source, error) {
+	switch source.CredentialsFrom {
+	case models.CredentialsFromSource:
+
+	case models.CredentialsFromVault:
+		secret, err := NewVaultClient(source.Vault, HTTPClient).Read(source.Vault.Path)
+		if err != nil {
+			return source, fmt.Errorf("reading credentials from vault: %w", err)
+		}
+
+		applyCredentials(&source, secret)
+
+	default:
+		return source, models.ValidationError{fmt.Sprintf("unknown credentials_from: %s", source.CredentialsFrom)}
+	}
+
+	if source.Driver == models.DriverGit && source.PrivateKeySecretID != "" {
+		config := &aws.Config{}
+		if source.PrivateKeySecretRegion != "" {
+			config.Region = aws.String(source.PrivateKeySecretRegion)
+		}
+
+		key, err := NewSecretsManager(config).GetSecretValue(source.PrivateKeySecretID)
+		if err != nil {
+			return source, fmt.Errorf("reading private key from secrets manager: %w", err)
+		}
+
+		source.PrivateKey = string(key)
+	}
+
+	return source, nil
+}
+
+// applyCredentials copies the credentials the driver needs out of a secret.
+// Besides the source's own property names, the keys issued by Vault's AWS
+// secrets engine are understood.
+func applyCredentials(source *models.Source, secret map[string]string) {
+	set := func(field *string, keys ...string) {
+		for _, key := range keys {
+			if value, found := secret[key]; found {
+				*field = value
+				return
+			}
+		}
+	}
+
+	switch source.Driver {
+	case models.DriverUnspecified, models.DriverS3:
+		set(&source.AccessKeyID, "access_key_id", "access_key")
+		set(&source.SecretAccessKey, "secret_access_key", "secret_key")
+		set(&source.SessionToken, "session_token", "security_token")
+
+	case models.DriverGit:
+		set(&source.PrivateKey, "private_key")
+		set(&source.Username, "username")
+		set(&source.Password, "password")
+
+	case models.DriverSwift:
+		set(&source.OpenStack.Username, "username")
+		set(&source.OpenStack.UserID, "user_id")
+		set(&source.OpenStack.Password, "password")
+		set(&source.OpenStack.APIKey, "api_key")
+		set(&source.OpenStack.TokenID, "token_id")
+	}
+}
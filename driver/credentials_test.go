@@ -0,0 +1,155 @@
+package driver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/concourse/semver-resource/driver"
+	"github.com/concourse/semver-resource/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResolveCredentials", func() {
+	It("leaves sources without credentials_from alone", func() {
+		source := models.Source{Driver: models.DriverGit, PrivateKey: "some-key"}
+
+		resolved, err := driver.ResolveCredentials(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved).To(Equal(source))
+	})
+
+	It("rejects unknown credential sources", func() {
+		_, err := driver.ResolveCredentials(models.Source{CredentialsFrom: "bogus"})
+		Expect(err).To(MatchError("unknown credentials_from: bogus"))
+	})
+
+	Describe("from vault", func() {
+		var server *httptest.Server
+		var secrets map[string]interface{}
+		var logins int
+
+		BeforeEach(func() {
+			logins = 0
+			secrets = map[string]interface{}{
+				"/v1/secret/data/ci": map[string]interface{}{
+					"data":     map[string]interface{}{"private_key": "kv2-key"},
+					"metadata": map[string]interface{}{"version": 3},
+				},
+				"/v1/aws/creds/semver": map[string]interface{}{
+					"access_key":     "dynamic-access-key",
+					"secret_key":     "dynamic-secret-key",
+					"security_token": "dynamic-session-token",
+				},
+				"/v1/openstack/semver": map[string]interface{}{
+					"username": "swift-user",
+					"password": "swift-password",
+				},
+			}
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v1/auth/approle/login" {
+					var login map[string]string
+					Expect(json.NewDecoder(r.Body).Decode(&login)).To(Succeed())
+
+					if login["role_id"] != "some-role" || login["secret_id"] != "some-secret" {
+						w.WriteHeader(http.StatusBadRequest)
+						json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+						return
+					}
+
+					logins++
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"auth": map[string]interface{}{"client_token": "approle-token"},
+					})
+					return
+				}
+
+				token := r.Header.Get("X-Vault-Token")
+				if token != "some-token" && token != "approle-token" {
+					w.WriteHeader(http.StatusForbidden)
+					json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+					return
+				}
+
+				secret, found := secrets[r.URL.Path]
+				if !found {
+					w.WriteHeader(http.StatusNotFound)
+					json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+					return
+				}
+
+				json.NewEncoder(w).Encode(map[string]interface{}{"data": secret})
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		vaultSource := func(driverName models.Driver, path string) models.Source {
+			return models.Source{
+				Driver:          driverName,
+				CredentialsFrom: models.CredentialsFromVault,
+				Vault: models.VaultOptions{
+					URL:   server.URL,
+					Path:  path,
+					Token: "some-token",
+				},
+			}
+		}
+
+		It("reads a git private key from a key/value secret", func() {
+			resolved, err := driver.ResolveCredentials(vaultSource(models.DriverGit, "secret/data/ci"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.PrivateKey).To(Equal("kv2-key"))
+		})
+
+		It("reads dynamic AWS credentials for s3", func() {
+			resolved, err := driver.ResolveCredentials(vaultSource(models.DriverS3, "aws/creds/semver"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.AccessKeyID).To(Equal("dynamic-access-key"))
+			Expect(resolved.SecretAccessKey).To(Equal("dynamic-secret-key"))
+			Expect(resolved.SessionToken).To(Equal("dynamic-session-token"))
+		})
+
+		It("reads OpenStack credentials for swift", func() {
+			resolved, err := driver.ResolveCredentials(vaultSource(models.DriverSwift, "openstack/semver"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.OpenStack.Username).To(Equal("swift-user"))
+			Expect(resolved.OpenStack.Password).To(Equal("swift-password"))
+		})
+
+		It("logs in with AppRole", func() {
+			source := vaultSource(models.DriverGit, "secret/data/ci")
+			source.Vault.Token = ""
+			source.Vault.RoleID = "some-role"
+			source.Vault.SecretID = "some-secret"
+
+			resolved, err := driver.ResolveCredentials(source)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.PrivateKey).To(Equal("kv2-key"))
+			Expect(logins).To(Equal(1))
+		})
+
+		It("fails when the login is rejected", func() {
+			source := vaultSource(models.DriverGit, "secret/data/ci")
+			source.Vault.Token = ""
+			source.Vault.RoleID = "some-role"
+			source.Vault.SecretID = "wrong"
+
+			_, err := driver.ResolveCredentials(source)
+			Expect(err).To(MatchError("reading credentials from vault: logging in: vault responded with 400 Bad Request: invalid role or secret ID"))
+		})
+
+		It("fails when the secret cannot be read", func() {
+			source := vaultSource(models.DriverGit, "secret/data/ci")
+			source.Vault.Token = "wrong"
+
+			_, err := driver.ResolveCredentials(source)
+			Expect(err).To(MatchError("reading credentials from vault: vault responded with 403 Forbidden: permission denied"))
+		})
+	})
+})
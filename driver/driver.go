@@ -2,6 +2,7 @@ package driver
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -18,14 +19,30 @@ type Driver interface {
 	Check(*semver.Version) ([]semver.Version, error)
 }
 
+// LastModifiedReader is implemented by drivers that know when the version was
+// last written. ok is false if no version is stored.
+type LastModifiedReader interface {
+	LastModified() (modified time.Time, ok bool, err error)
+}
+
 const maxRetries = 12
 
 func FromSource(source models.Source) (Driver, error) {
+	source, err := ResolveCredentials(source)
+	if err != nil {
+		return nil, err
+	}
+
+	err = models.Validate(source)
+	if err != nil {
+		return nil, err
+	}
+
 	var initialVersion semver.Version
 	if source.InitialVersion != "" {
 		version, err := semver.Parse(source.InitialVersion)
 		if err != nil {
-			return nil, fmt.Errorf("invalid initial version (%s): %s", source.InitialVersion, err)
+			return nil, models.ValidationError{fmt.Sprintf("invalid initial version (%s): %s", source.InitialVersion, err)}
 		}
 
 		initialVersion = version
@@ -33,6 +50,11 @@ func FromSource(source models.Source) (Driver, error) {
 		initialVersion = semver.Version{Major: 0, Minor: 0, Patch: 0}
 	}
 
+	attachments, err := attachmentsFor(source)
+	if err != nil {
+		return nil, err
+	}
+
 	switch source.Driver {
 	case models.DriverUnspecified, models.DriverS3:
 		var creds *credentials.Credentials
@@ -40,7 +62,7 @@ func FromSource(source models.Source) (Driver, error) {
 		if source.AccessKeyID == "" && source.SecretAccessKey == "" {
 			creds = credentials.AnonymousCredentials
 		} else {
-			creds = credentials.NewStaticCredentials(source.AccessKeyID, source.SecretAccessKey, "")
+			creds = credentials.NewStaticCredentials(source.AccessKeyID, source.SecretAccessKey, source.SessionToken)
 		}
 
 		regionName := source.RegionName
@@ -54,6 +76,7 @@ func FromSource(source models.Source) (Driver, error) {
 			S3ForcePathStyle: aws.Bool(true),
 			MaxRetries:       aws.Int(maxRetries),
 			DisableSSL:       aws.Bool(source.DisableSSL),
+			HTTPClient:       HTTPClient,
 		}
 
 		if len(source.Endpoint) != 0 {
@@ -68,6 +91,8 @@ func FromSource(source models.Source) (Driver, error) {
 			Svc:        svc,
 			BucketName: source.Bucket,
 			Key:        source.Key,
+
+			Attachments: attachments,
 		}, nil
 
 	case models.DriverGit:
@@ -81,12 +106,14 @@ func FromSource(source models.Source) (Driver, error) {
 			Password:   source.Password,
 			File:       source.File,
 			GitUser:    source.GitUser,
+
+			Attachments: attachments,
 		}, nil
 
 	case models.DriverSwift:
 		return NewSwiftDriver(&source)
 
 	default:
-		return nil, fmt.Errorf("unknown driver: %s", source.Driver)
+		return nil, models.ValidationError{fmt.Sprintf("unknown driver: %s", source.Driver)}
 	}
 }
@@ -0,0 +1,225 @@
+package driver
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/rackspace/gophercloud"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/policy"
+	"github.com/concourse/semver-resource/signing"
+)
+
+// FailureCode classifies why an operation failed, so that tooling can branch
+// on it. Codes are stable; their messages are not.
+type FailureCode string
+
+const (
+	FailureInvalidConfig  FailureCode = "invalid_config"
+	FailurePolicyRejected FailureCode = "policy_rejected"
+	FailureFrozen         FailureCode = "frozen"
+	FailureCoolingDown    FailureCode = "cooling_down"
+	FailureNotReserved    FailureCode = "not_reserved"
+	FailureReserved       FailureCode = "reserved"
+	FailureUnauthorized   FailureCode = "unauthorized"
+	FailureNotFound       FailureCode = "not_found"
+	FailureThrottled      FailureCode = "throttled"
+	FailureUnavailable    FailureCode = "unavailable"
+	FailureNetwork        FailureCode = "network"
+	FailureCommandFailed  FailureCode = "command_failed"
+	FailureFilesystem     FailureCode = "filesystem"
+	FailureUnknown        FailureCode = "unknown"
+)
+
+// Failure is a machine-readable description of a failed operation.
+type Failure struct {
+	Code   FailureCode   `json:"code"`
+	Driver models.Driver `json:"driver,omitempty"`
+
+	// Operation is the resource operation that failed: check, in or out.
+	Operation string `json:"operation"`
+
+	// Retryable is true if the same operation may succeed if tried again
+	// later, e.g. once a network problem or freeze window has passed.
+	Retryable bool `json:"retryable"`
+
+	Message string `json:"message"`
+}
+
+// NewFailure classifies err, which made operation fail. source is nil if the
+// request could not be read.
+func NewFailure(operation string, source *models.Source, message string, err error) Failure {
+	code, retryable := Classify(err)
+
+	failure := Failure{
+		Code:      code,
+		Operation: operation,
+		Retryable: retryable,
+		Message:   message,
+	}
+
+	if source != nil {
+		failure.Driver = source.Driver
+		if failure.Driver == models.DriverUnspecified {
+			failure.Driver = models.DriverS3
+		}
+	}
+
+	return failure
+}
+
+// Classify returns the code of err, and whether the operation failing with it
+// may be retried.
+func Classify(err error) (FailureCode, bool) {
+	var validation models.ValidationError
+	var syntax *json.SyntaxError
+	var mistyped *json.UnmarshalTypeError
+	var rejection policy.Rejection
+	var readOnly policy.ReadOnlyNamespace
+	var frozen policy.Frozen
+	var cooling policy.CoolingDown
+	var notReserved NotReserved
+	var reserved Reserved
+	var vault VaultError
+	var swift *gophercloud.UnexpectedResponseCodeError
+	var requestFailure awserr.RequestFailure
+	var aws awserr.Error
+	var gitErr GitError
+	var netErr net.Error
+	var exit *exec.ExitError
+	var pathErr *os.PathError
+
+	switch {
+	case errors.As(err, &validation),
+		errors.As(err, &syntax),
+		errors.As(err, &mistyped),
+		errors.Is(err, ErrEncryptedKey),
+		errors.Is(err, signing.ErrEncryptedKey):
+		return FailureInvalidConfig, false
+
+	case errors.As(err, &rejection), errors.As(err, &readOnly):
+		return FailurePolicyRejected, false
+
+	case errors.As(err, &frozen):
+		return FailureFrozen, true
+
+	case errors.As(err, &cooling):
+		return FailureCoolingDown, true
+
+	case errors.As(err, &notReserved):
+		return FailureNotReserved, false
+
+	case errors.As(err, &reserved):
+		return FailureReserved, false
+
+	case errors.As(err, &vault):
+		return classifyStatus(vault.StatusCode)
+
+	case errors.As(err, &swift):
+		return classifyStatus(swift.Actual)
+
+	case errors.As(err, &requestFailure) && requestFailure.StatusCode() != 0:
+		code, retryable := classifyAWSCode(requestFailure.Code())
+		if code != FailureUnknown {
+			return code, retryable
+		}
+
+		return classifyStatus(requestFailure.StatusCode())
+
+	case errors.As(err, &aws):
+		return classifyAWSCode(aws.Code())
+
+	case errors.As(err, &gitErr):
+		return classifyGitOutput(gitErr.Output)
+
+	// path errors have a Timeout method, so satisfy net.Error
+	case errors.As(err, &pathErr):
+		return FailureFilesystem, false
+
+	case errors.As(err, &netErr):
+		return FailureNetwork, true
+
+	case errors.As(err, &exit):
+		return FailureCommandFailed, false
+	}
+
+	return FailureUnknown, false
+}
+
+func classifyStatus(status int) (FailureCode, bool) {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return FailureUnauthorized, false
+	case status == http.StatusNotFound:
+		return FailureNotFound, false
+	case status == http.StatusTooManyRequests:
+		return FailureThrottled, true
+	case status >= 500:
+		return FailureUnavailable, true
+	}
+
+	return FailureUnknown, false
+}
+
+// gitFailures are what git prints when it fails for a known reason, over
+// https or ssh.
+var gitFailures = []struct {
+	output    string
+	code      FailureCode
+	retryable bool
+}{
+	{"Could not resolve host", FailureNetwork, true},
+	{"Could not resolve hostname", FailureNetwork, true},
+	{"Connection timed out", FailureNetwork, true},
+	{"Operation timed out", FailureNetwork, true},
+	{"Connection refused", FailureNetwork, true},
+	{"Connection reset", FailureNetwork, true},
+	{"Network is unreachable", FailureNetwork, true},
+	{"Failed to connect", FailureNetwork, true},
+	{"Authentication failed", FailureUnauthorized, false},
+	{"Permission denied", FailureUnauthorized, false},
+	{"could not read Username", FailureUnauthorized, false},
+	{"The requested URL returned error: 401", FailureUnauthorized, false},
+	{"The requested URL returned error: 403", FailureUnauthorized, false},
+	{"Repository not found", FailureNotFound, false},
+	{"does not appear to be a git repository", FailureNotFound, false},
+	{"not found in upstream origin", FailureNotFound, false},
+	{"couldn't find remote ref", FailureNotFound, false},
+	{"The requested URL returned error: 404", FailureNotFound, false},
+	{"The requested URL returned error: 429", FailureThrottled, true},
+	{"The requested URL returned error: 5", FailureUnavailable, true},
+}
+
+func classifyGitOutput(output string) (FailureCode, bool) {
+	for _, failure := range gitFailures {
+		if strings.Contains(output, failure.output) {
+			return failure.code, failure.retryable
+		}
+	}
+
+	return FailureCommandFailed, false
+}
+
+func classifyAWSCode(code string) (FailureCode, bool) {
+	switch code {
+	case "AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch", "ExpiredToken", "NoCredentialProviders", "UnrecognizedClientException":
+		return FailureUnauthorized, false
+	case "NoSuchBucket", "NoSuchKey", "ResourceNotFoundException":
+		return FailureNotFound, false
+	case "Throttling", "ThrottlingException", "SlowDown", "RequestLimitExceeded":
+		return FailureThrottled, true
+	case "RequestError", "RequestTimeout":
+		return FailureNetwork, true
+	case "ServiceUnavailable", "InternalError":
+		return FailureUnavailable, true
+	}
+
+	return FailureUnknown, false
+}
@@ -0,0 +1,127 @@
+package driver_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/rackspace/gophercloud"
+
+	"github.com/concourse/semver-resource/driver"
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/policy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Failures", func() {
+	classify := func(err error) driver.FailureCode {
+		code, _ := driver.Classify(err)
+		return code
+	}
+
+	retryable := func(err error) bool {
+		_, retryable := driver.Classify(err)
+		return retryable
+	}
+
+	It("classifies configuration problems", func() {
+		Expect(classify(models.ValidationError{"bucket is required"})).To(Equal(driver.FailureInvalidConfig))
+		Expect(classify(driver.ErrEncryptedKey)).To(Equal(driver.FailureInvalidConfig))
+
+		var source models.Source
+		err := json.Unmarshal([]byte(`{"disable_ssl": "yes"}`), &source)
+		Expect(classify(err)).To(Equal(driver.FailureInvalidConfig))
+
+		_, err = driver.FromSource(models.Source{Driver: "ftp"})
+		Expect(classify(err)).To(Equal(driver.FailureInvalidConfig))
+
+		_, err = policy.FromSource(models.Source{MinBumpInterval: "soon"}, nil)
+		Expect(classify(err)).To(Equal(driver.FailureInvalidConfig))
+	})
+
+	It("classifies policy decisions", func() {
+		Expect(classify(policy.Rejection{})).To(Equal(driver.FailurePolicyRejected))
+		Expect(classify(policy.ReadOnlyNamespace{})).To(Equal(driver.FailurePolicyRejected))
+		Expect(retryable(policy.Rejection{})).To(BeFalse())
+
+		Expect(classify(policy.Frozen{})).To(Equal(driver.FailureFrozen))
+		Expect(retryable(policy.Frozen{})).To(BeTrue())
+
+		Expect(classify(policy.CoolingDown{})).To(Equal(driver.FailureCoolingDown))
+		Expect(retryable(policy.CoolingDown{})).To(BeTrue())
+	})
+
+	It("classifies reservations that do not exist", func() {
+		Expect(classify(driver.NotReserved{})).To(Equal(driver.FailureNotReserved))
+	})
+
+	It("classifies versions set while reserved", func() {
+		Expect(classify(driver.Reserved{})).To(Equal(driver.FailureReserved))
+		Expect(retryable(driver.Reserved{})).To(BeFalse())
+	})
+
+	It("classifies responses by status", func() {
+		Expect(classify(driver.VaultError{StatusCode: 403})).To(Equal(driver.FailureUnauthorized))
+		Expect(classify(fmt.Errorf("reading credentials from vault: %w", driver.VaultError{StatusCode: 503}))).To(Equal(driver.FailureUnavailable))
+		Expect(retryable(driver.VaultError{StatusCode: 503})).To(BeTrue())
+
+		Expect(classify(&gophercloud.UnexpectedResponseCodeError{Actual: 404})).To(Equal(driver.FailureNotFound))
+		Expect(classify(&gophercloud.UnexpectedResponseCodeError{Actual: 429})).To(Equal(driver.FailureThrottled))
+	})
+
+	It("classifies AWS errors by code, then status", func() {
+		Expect(classify(awserr.NewRequestFailure(awserr.New("NoSuchBucket", "no such bucket", nil), 404, "id"))).To(Equal(driver.FailureNotFound))
+		Expect(classify(awserr.NewRequestFailure(awserr.New("SlowDown", "slow down", nil), 503, "id"))).To(Equal(driver.FailureThrottled))
+		Expect(classify(awserr.NewRequestFailure(awserr.New("Whatever", "forbidden", nil), 403, "id"))).To(Equal(driver.FailureUnauthorized))
+
+		sendFailed := awserr.New("RequestError", "send request failed", errors.New("connection refused"))
+		Expect(classify(sendFailed)).To(Equal(driver.FailureNetwork))
+		Expect(retryable(sendFailed)).To(BeTrue())
+	})
+
+	It("classifies network, command and filesystem errors", func() {
+		Expect(classify(&net.OpError{Op: "dial", Err: errors.New("connection refused")})).To(Equal(driver.FailureNetwork))
+
+		err := exec.Command("false").Run()
+		Expect(classify(err)).To(Equal(driver.FailureCommandFailed))
+
+		_, err = os.Open("/does/not/exist")
+		Expect(classify(err)).To(Equal(driver.FailureFilesystem))
+	})
+
+	It("classifies git failures by their output", func() {
+		Expect(classify(driver.GitError{Command: "clone", Output: "fatal: unable to access 'https://github.com/org/versions.git/': Could not resolve host: github.com\n"})).To(Equal(driver.FailureNetwork))
+		Expect(retryable(driver.GitError{Command: "fetch", Output: "ssh: connect to host github.com port 22: Connection timed out\n"})).To(BeTrue())
+
+		Expect(classify(driver.GitError{Command: "push", Output: "git@github.com: Permission denied (publickey).\n"})).To(Equal(driver.FailureUnauthorized))
+		Expect(classify(driver.GitError{Command: "clone", Output: "fatal: Remote branch main not found in upstream origin\n"})).To(Equal(driver.FailureNotFound))
+
+		err := fmt.Errorf("bumping: %w", driver.GitError{Command: "push", Output: "error: something odd\n", Err: exec.Command("false").Run()})
+		Expect(classify(err)).To(Equal(driver.FailureCommandFailed))
+		Expect(retryable(err)).To(BeFalse())
+	})
+
+	It("classifies anything else as unknown", func() {
+		Expect(classify(errors.New("something odd"))).To(Equal(driver.FailureUnknown))
+		Expect(retryable(errors.New("something odd"))).To(BeFalse())
+	})
+
+	It("describes failures with the driver of the source", func() {
+		failure := driver.NewFailure("out", &models.Source{}, "error checking policy: nope", policy.Rejection{})
+		Expect(failure).To(Equal(driver.Failure{
+			Code:      driver.FailurePolicyRejected,
+			Driver:    models.DriverS3,
+			Operation: "out",
+			Retryable: false,
+			Message:   "error checking policy: nope",
+		}))
+
+		Expect(driver.NewFailure("check", nil, "error reading request: EOF", errors.New("EOF")).Driver).To(BeEmpty())
+	})
+})
@@ -1,16 +1,21 @@
 package driver
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/mail"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/tracing"
 	"github.com/concourse/semver-resource/version"
 )
 
@@ -20,10 +25,46 @@ var netRcPath string
 
 var ErrEncryptedKey = errors.New("private keys with passphrases are not supported")
 
+// GitError is a git command that failed. Its output is the only way to tell
+// why, as git exits with the same status for most failures.
+type GitError struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+func (err GitError) Error() string {
+	return fmt.Sprintf("git %s failed: %s", err.Command, err.Err)
+}
+
+func (err GitError) Unwrap() error {
+	return err.Err
+}
+
 func init() {
 	gitRepoDir = filepath.Join(os.TempDir(), "semver-git-repo")
 	privateKeyPath = filepath.Join(os.TempDir(), "private-key")
-	netRcPath = filepath.Join(os.Getenv("HOME"), ".netrc")
+	netRcPath = filepath.Join(homeDir(), netRcFileName)
+}
+
+// UseHome makes git, and the key and netrc the git driver writes for it, use
+// dir instead of the user's home directory, for tools run outside of a
+// container that must leave ~/.netrc and the global git config alone.
+func UseHome(dir string) error {
+	err := os.Setenv("HOME", dir)
+	if err != nil {
+		return err
+	}
+
+	err = os.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(dir, ".gitconfig"))
+	if err != nil {
+		return err
+	}
+
+	privateKeyPath = filepath.Join(dir, "private-key")
+	netRcPath = filepath.Join(dir, netRcFileName)
+
+	return nil
 }
 
 type GitDriver struct {
@@ -36,6 +77,23 @@ type GitDriver struct {
 	Password   string
 	File       string
 	GitUser    string
+
+	Attachments []Attachment
+	Guards      []Guard
+
+	// RepoDir is where the repository is cloned, defaulting to a directory
+	// shared by every operation in the container.
+	RepoDir string
+
+	conflicts int
+}
+
+func (driver *GitDriver) repoDir() string {
+	if driver.RepoDir != "" {
+		return driver.RepoDir
+	}
+
+	return gitRepoDir
 }
 
 func (driver *GitDriver) Bump(bump version.Bump) (semver.Version, error) {
@@ -66,12 +124,34 @@ func (driver *GitDriver) Bump(bump version.Bump) (semver.Version, error) {
 			currentVersion = driver.InitialVersion
 		}
 
-		newVersion = bump.Apply(currentVersion)
+		reservations, err := driver.readReservations()
+		if err != nil {
+			return semver.Version{}, err
+		}
+
+		// reserved versions are left for the builds holding them to commit
+		newVersion, err = nextReservation(currentVersion, reservations, bump)
+		if err != nil {
+			return semver.Version{}, err
+		}
+
+		change := changeFrom(currentVersion, exists, newVersion, bump.String())
+
+		err = checkChange(driver.Guards, change, driver.lastCommitted)
+		if err != nil {
+			return semver.Version{}, err
+		}
+
+		wrote, err := driver.writeVersion(change)
+		if err != nil {
+			return semver.Version{}, err
+		}
 
-		wrote, err := driver.writeVersion(newVersion)
 		if wrote {
 			break
 		}
+
+		driver.conflicts++
 	}
 
 	return newVersion, nil
@@ -94,7 +174,26 @@ func (driver *GitDriver) Set(newVersion semver.Version) error {
 			return err
 		}
 
-		wrote, err := driver.writeVersion(newVersion)
+		reservations, err := driver.readReservations()
+		if err != nil {
+			return err
+		}
+
+		if isReserved(reservations, newVersion) {
+			return Reserved{Version: newVersion}
+		}
+
+		change, err := setChange(driver.Attachments, driver.Guards, driver.readVersion, newVersion)
+		if err != nil {
+			return err
+		}
+
+		err = checkChange(driver.Guards, change, driver.lastCommitted)
+		if err != nil {
+			return err
+		}
+
+		wrote, err := driver.writeVersion(change)
 		if err != nil {
 			return err
 		}
@@ -102,11 +201,187 @@ func (driver *GitDriver) Set(newVersion semver.Version) error {
 		if wrote {
 			break
 		}
+
+		driver.conflicts++
 	}
 
 	return nil
 }
 
+// Reserve records the next version in the reservations file next to the
+// version, retrying until it wins any race with other writers.
+func (driver *GitDriver) Reserve(bump version.Bump) (semver.Version, error) {
+	var reserved semver.Version
+
+	err := driver.updateReservations(func(current semver.Version, exists bool, reservations []semver.Version) ([]semver.Version, string, []string, error) {
+		var err error
+		reserved, err = nextReservation(current, reservations, bump)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		err = checkChange(driver.Guards, changeFrom(current, exists, reserved, bump.String()), driver.lastCommitted)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		return append(reservations, reserved), "reserve " + reserved.String(), nil, nil
+	})
+
+	return reserved, err
+}
+
+// Commit writes a reserved version, if it is later than the current one, in
+// the same commit that releases it.
+func (driver *GitDriver) Commit(reserved semver.Version) (bool, error) {
+	var published bool
+
+	err := driver.updateReservations(func(current semver.Version, exists bool, reservations []semver.Version) ([]semver.Version, string, []string, error) {
+		remaining, err := release(reservations, reserved)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		published = !exists || reserved.GT(current)
+		if !published {
+			return remaining, "release " + reserved.String(), nil, nil
+		}
+
+		change := changeFrom(current, exists, reserved, setBump)
+
+		err = checkChange(driver.Guards, change, driver.lastCommitted)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		written, err := driver.writeVersionFiles(change)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		return remaining, "bump to " + reserved.String(), written, nil
+	})
+
+	return published, err
+}
+
+// Abandon releases a reservation.
+func (driver *GitDriver) Abandon(reserved semver.Version) error {
+	return driver.updateReservations(func(current semver.Version, exists bool, reservations []semver.Version) ([]semver.Version, string, []string, error) {
+		remaining, err := release(reservations, reserved)
+		return remaining, "abandon " + reserved.String(), nil, err
+	})
+}
+
+// updateReservations rewrites the reservations file with those returned by
+// update, given the current version and reservations, and commits it together
+// with any other files update wrote.
+func (driver *GitDriver) updateReservations(update func(current semver.Version, exists bool, reservations []semver.Version) ([]semver.Version, string, []string, error)) error {
+	err := driver.setUpAuth()
+	if err != nil {
+		return err
+	}
+
+	err = driver.setUserInfo()
+	if err != nil {
+		return err
+	}
+
+	name := ReservationsName(driver.File)
+
+	for {
+		err = driver.setUpRepo()
+		if err != nil {
+			return err
+		}
+
+		currentVersion, exists, err := driver.readVersion()
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			currentVersion = driver.InitialVersion
+		}
+
+		reservations, err := driver.readReservations()
+		if err != nil {
+			return err
+		}
+
+		reservations, message, written, err := update(currentVersion, exists, reservations)
+		if err != nil {
+			return err
+		}
+
+		err = os.MkdirAll(filepath.Dir(filepath.Join(driver.repoDir(), name)), 0755)
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(filepath.Join(driver.repoDir(), name), formatReservations(reservations), 0644)
+		if err != nil {
+			return err
+		}
+
+		wrote, err := driver.commitAndPush(message, append(written, name))
+		if err != nil {
+			return err
+		}
+
+		if wrote {
+			return nil
+		}
+
+		driver.conflicts++
+	}
+}
+
+// readReservations reads the reservations file in the checkout.
+func (driver *GitDriver) readReservations() ([]semver.Version, error) {
+	content, err := ioutil.ReadFile(filepath.Join(driver.repoDir(), ReservationsName(driver.File)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return parseReservations(content)
+}
+
+// Attach adds an attachment to be committed together with every change.
+func (driver *GitDriver) Attach(attachment Attachment) {
+	driver.Attachments = append(driver.Attachments, attachment)
+}
+
+// Guard adds a guard to check every change with before it is committed.
+func (driver *GitDriver) Guard(guard Guard) {
+	driver.Guards = append(driver.Guards, guard)
+}
+
+// Conflicts returns how many writes lost a race with another push and had to
+// be retried.
+func (driver *GitDriver) Conflicts() int {
+	return driver.conflicts
+}
+
+func (driver *GitDriver) ReadAttachment(name string) ([]byte, error) {
+	err := driver.setUpAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	err = driver.setUpRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(driver.repoDir(), name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return content, err
+}
+
 func (driver *GitDriver) Check(cursor *semver.Version) ([]semver.Version, error) {
 	err := driver.setUpAuth()
 	if err != nil {
@@ -134,30 +409,74 @@ func (driver *GitDriver) Check(cursor *semver.Version) ([]semver.Version, error)
 	return []semver.Version{}, nil
 }
 
+// LastModified is when the version file was last committed.
+func (driver *GitDriver) LastModified() (time.Time, bool, error) {
+	err := driver.setUpAuth()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	err = driver.setUpRepo()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return driver.lastCommitted()
+}
+
+// lastCommitted is when the version file was last committed in the checkout,
+// without fetching first.
+func (driver *GitDriver) lastCommitted() (time.Time, bool, error) {
+	gitLog := exec.Command("git", "log", "-1", "--format=%ct", "--", driver.File)
+	gitLog.Dir = driver.repoDir()
+	gitLog.Stderr = os.Stderr
+
+	output, err := gitLog.Output()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	committed := strings.TrimSpace(string(output))
+	if committed == "" {
+		return time.Time{}, false, nil
+	}
+
+	seconds, err := strconv.ParseInt(committed, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("parsing commit time: %s", err)
+	}
+
+	return time.Unix(seconds, 0).UTC(), true, nil
+}
+
 func (driver *GitDriver) setUpRepo() error {
-	_, err := os.Stat(gitRepoDir)
+	_, err := os.Stat(driver.repoDir())
 	if err != nil {
-		gitClone := exec.Command("git", "clone", driver.URI, "--branch", driver.Branch, gitRepoDir)
-		gitClone.Stdout = os.Stderr
-		gitClone.Stderr = os.Stderr
-		if err := gitClone.Run(); err != nil {
+		span := tracing.Start("git.clone")
+		span.SetAttribute("git.branch", driver.Branch)
+
+		gitClone := exec.Command("git", "clone", driver.URI, "--branch", driver.Branch, driver.repoDir())
+		err := runGit(gitClone)
+		span.End(err)
+		if err != nil {
 			return err
 		}
 	} else {
+		span := tracing.Start("git.fetch")
+		span.SetAttribute("git.branch", driver.Branch)
+
 		gitFetch := exec.Command("git", "fetch", "origin", driver.Branch)
-		gitFetch.Dir = gitRepoDir
-		gitFetch.Stdout = os.Stderr
-		gitFetch.Stderr = os.Stderr
-		if err := gitFetch.Run(); err != nil {
+		gitFetch.Dir = driver.repoDir()
+		err := runGit(gitFetch)
+		span.End(err)
+		if err != nil {
 			return err
 		}
 	}
 
 	gitCheckout := exec.Command("git", "reset", "--hard", "origin/"+driver.Branch)
-	gitCheckout.Dir = gitRepoDir
-	gitCheckout.Stdout = os.Stderr
-	gitCheckout.Stderr = os.Stderr
-	if err := gitCheckout.Run(); err != nil {
+	gitCheckout.Dir = driver.repoDir()
+	if err := runGit(gitCheckout); err != nil {
 		return err
 	}
 
@@ -182,6 +501,11 @@ func (driver *GitDriver) setUpAuth() error {
 		if err != nil {
 			return err
 		}
+	} else {
+		err := driver.tearDownKey()
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(driver.Username) > 0 && len(driver.Password) > 0 {
@@ -194,24 +518,58 @@ func (driver *GitDriver) setUpAuth() error {
 	return nil
 }
 
+// setUpKey writes the private key for ssh to use. It is rewritten for every
+// operation, as containers are reused and the key may have been rotated
+// since the last one.
 func (driver *GitDriver) setUpKey() error {
 	if strings.Contains(driver.PrivateKey, "ENCRYPTED") {
 		return ErrEncryptedKey
 	}
 
-	_, err := os.Stat(privateKeyPath)
+	// write to a new file and swap it in, so that concurrent operations never
+	// see a partially written key, nor write to the same new file
+	tmpFile, err := ioutil.TempFile(filepath.Dir(privateKeyPath), filepath.Base(privateKeyPath)+"-")
 	if err != nil {
-		if os.IsNotExist(err) {
-			err := ioutil.WriteFile(privateKeyPath, []byte(driver.PrivateKey), 0600)
-			if err != nil {
-				return err
-			}
-		} else {
-			return err
-		}
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(driver.PrivateKey)
+	tmpFile.Close()
+	if err != nil {
+		return err
 	}
 
-	return os.Setenv("GIT_SSH_COMMAND", "ssh -o StrictHostKeyChecking=no -i "+privateKeyPath)
+	err = restrictToOwner(tmpFile.Name())
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpFile.Name(), privateKeyPath)
+	if err != nil {
+		return err
+	}
+
+	return os.Setenv("GIT_SSH_COMMAND", sshCommand(privateKeyPath))
+}
+
+// tearDownKey removes any key written by a previous operation which was
+// configured with one.
+func (driver *GitDriver) tearDownKey() error {
+	err := os.Remove(privateKeyPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return os.Unsetenv("GIT_SSH_COMMAND")
+}
+
+// sshCommand builds the value for GIT_SSH_COMMAND. Git always runs it through
+// a POSIX shell (bundled with Git for Windows), so the key path is quoted and
+// given forward slashes to survive spaces and backslashes in Windows paths.
+func sshCommand(keyPath string) string {
+	return fmt.Sprintf(`ssh -o StrictHostKeyChecking=no -i "%s"`, filepath.ToSlash(keyPath))
 }
 
 func (driver *GitDriver) setUpUsernamePassword() error {
@@ -223,6 +581,11 @@ func (driver *GitDriver) setUpUsernamePassword() error {
 			if err != nil {
 				return err
 			}
+
+			err = restrictToOwner(netRcPath)
+			if err != nil {
+				return err
+			}
 		} else {
 			return err
 		}
@@ -260,8 +623,18 @@ func (driver *GitDriver) setUserInfo() error {
 }
 
 func (driver *GitDriver) readVersion() (semver.Version, bool, error) {
+	span := tracing.Start("git.read")
+	span.SetAttribute("git.file", driver.File)
+
+	currentVersion, exists, err := driver.readVersionFile()
+	span.End(err)
+
+	return currentVersion, exists, err
+}
+
+func (driver *GitDriver) readVersionFile() (semver.Version, bool, error) {
 	var currentVersionStr string
-	versionFile, err := os.Open(filepath.Join(gitRepoDir, driver.File))
+	versionFile, err := os.Open(filepath.Join(driver.repoDir(), driver.File))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return semver.Version{}, false, nil
@@ -274,12 +647,12 @@ func (driver *GitDriver) readVersion() (semver.Version, bool, error) {
 
 	_, err = fmt.Fscanf(versionFile, "%s", &currentVersionStr)
 	if err != nil {
-		return semver.Version{}, false, err
+		return semver.Version{}, false, invalidVersion{err}
 	}
 
 	currentVersion, err := semver.Parse(currentVersionStr)
 	if err != nil {
-		return semver.Version{}, false, err
+		return semver.Version{}, false, invalidVersion{err}
 	}
 
 	return currentVersion, true, nil
@@ -290,38 +663,75 @@ const falsePushString = "Everything up-to-date"
 const pushRejectedString = "[rejected]"
 const pushRemoteRejectedString = "[remote rejected]"
 
-func (driver *GitDriver) writeVersion(newVersion semver.Version) (bool, error) {
-	err := ioutil.WriteFile(filepath.Join(gitRepoDir, driver.File), []byte(newVersion.String()+"\n"), 0644)
+func (driver *GitDriver) writeVersion(change Change) (bool, error) {
+	written, err := driver.writeVersionFiles(change)
 	if err != nil {
 		return false, err
 	}
 
-	gitAdd := exec.Command("git", "add", driver.File)
-	gitAdd.Dir = gitRepoDir
+	return driver.commitAndPush("bump to "+change.To.String(), written)
+}
+
+// writeVersionFiles writes the version, and its attachments, into the working
+// tree, returning the files written.
+func (driver *GitDriver) writeVersionFiles(change Change) ([]string, error) {
+	err := os.MkdirAll(filepath.Dir(filepath.Join(driver.repoDir(), driver.File)), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(driver.repoDir(), driver.File), []byte(change.To.String()+"\n"), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	attached, err := driver.writeAttachments(change)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{driver.File}, attached...), nil
+}
+
+// commitAndPush commits files and pushes them to the branch, returning false
+// if the push lost a race with another one and should be retried from a fresh
+// checkout.
+func (driver *GitDriver) commitAndPush(message string, files []string) (bool, error) {
+	gitAdd := exec.Command("git", append([]string{"add"}, files...)...)
+	gitAdd.Dir = driver.repoDir()
 	gitAdd.Stdout = os.Stderr
 	gitAdd.Stderr = os.Stderr
 	if err := gitAdd.Run(); err != nil {
 		return false, err
 	}
 
-	gitCommit := exec.Command("git", "commit", "-m", "bump to "+newVersion.String())
-	gitCommit.Dir = gitRepoDir
+	commitSpan := tracing.Start("git.commit")
+
+	gitCommit := exec.Command("git", "commit", "-m", message)
+	gitCommit.Dir = driver.repoDir()
 
 	commitOutput, err := gitCommit.CombinedOutput()
 
 	if strings.Contains(string(commitOutput), nothingToCommitString) {
+		commitSpan.End(nil)
 		return true, nil
 	}
 
+	commitSpan.End(err)
+
 	if err != nil {
 		os.Stderr.Write(commitOutput)
-		return false, err
+		return false, GitError{Command: "commit", Output: string(commitOutput), Err: err}
 	}
 
 	gitPush := exec.Command("git", "push", "origin", "HEAD:"+driver.Branch)
-	gitPush.Dir = gitRepoDir
+	gitPush.Dir = driver.repoDir()
+
+	pushSpan := tracing.Start("git.push")
+	pushSpan.SetAttribute("git.branch", driver.Branch)
 
 	pushOutput, err := gitPush.CombinedOutput()
+	pushSpan.End(err)
 
 	if strings.Contains(string(pushOutput), falsePushString) {
 		return false, nil
@@ -337,8 +747,57 @@ func (driver *GitDriver) writeVersion(newVersion semver.Version) (bool, error) {
 
 	if err != nil {
 		os.Stderr.Write(pushOutput)
-		return false, err
+		return false, GitError{Command: "push", Output: string(pushOutput), Err: err}
 	}
 
 	return true, nil
 }
+
+// runGit runs a git command, copying its output to stderr, and returns a
+// GitError with the output if it fails.
+func runGit(command *exec.Cmd) error {
+	output := new(bytes.Buffer)
+	command.Stdout = io.MultiWriter(os.Stderr, output)
+	command.Stderr = command.Stdout
+
+	err := command.Run()
+	if err != nil {
+		return GitError{Command: command.Args[1], Output: output.String(), Err: err}
+	}
+
+	return nil
+}
+
+// writeAttachments renders every attachment into the working tree so that it
+// is committed along with the version, returning the files written.
+func (driver *GitDriver) writeAttachments(change Change) ([]string, error) {
+	written := []string{}
+
+	for _, attachment := range driver.Attachments {
+		path := filepath.Join(driver.repoDir(), attachment.Name)
+
+		previous, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		content, err := attachment.Render(previous, change)
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return nil, err
+		}
+
+		err = ioutil.WriteFile(path, content, 0644)
+		if err != nil {
+			return nil, err
+		}
+
+		written = append(written, attachment.Name)
+	}
+
+	return written, nil
+}
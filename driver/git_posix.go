@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package driver
+
+import "os"
+
+const netRcFileName = ".netrc"
+
+func homeDir() string {
+	return os.Getenv("HOME")
+}
+
+// restrictToOwner is a no-op on POSIX systems; the files are already written
+// with mode 0600.
+func restrictToOwner(path string) error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("sshCommand", func() {
+	It("quotes the key path", func() {
+		Expect(sshCommand("/tmp/some dir/private-key")).To(Equal(`ssh -o StrictHostKeyChecking=no -i "/tmp/some dir/private-key"`))
+	})
+})
+
+var _ = Describe("setUpAuth", func() {
+	var originalKeyPath string
+	var originalNetRcPath string
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "git-auth")
+		Expect(err).NotTo(HaveOccurred())
+
+		originalKeyPath = privateKeyPath
+		originalNetRcPath = netRcPath
+		privateKeyPath = filepath.Join(tmpDir, "private-key")
+		netRcPath = filepath.Join(tmpDir, ".netrc")
+	})
+
+	AfterEach(func() {
+		privateKeyPath = originalKeyPath
+		netRcPath = originalNetRcPath
+		os.Unsetenv("GIT_SSH_COMMAND")
+		os.RemoveAll(tmpDir)
+	})
+
+	It("rewrites the private key every time, picking up rotated keys", func() {
+		Expect((&GitDriver{PrivateKey: "old-key"}).setUpAuth()).To(Succeed())
+		Expect(ioutil.ReadFile(privateKeyPath)).To(Equal([]byte("old-key")))
+
+		Expect((&GitDriver{PrivateKey: "new-key"}).setUpAuth()).To(Succeed())
+		Expect(ioutil.ReadFile(privateKeyPath)).To(Equal([]byte("new-key")))
+		Expect(os.Getenv("GIT_SSH_COMMAND")).To(Equal(sshCommand(privateKeyPath)))
+	})
+
+	It("leaves only the key readable by its owner behind", func() {
+		Expect((&GitDriver{PrivateKey: "key"}).setUpAuth()).To(Succeed())
+		Expect((&GitDriver{PrivateKey: "key"}).setUpAuth()).To(Succeed())
+
+		files, err := ioutil.ReadDir(tmpDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Name()).To(Equal("private-key"))
+
+		if runtime.GOOS != "windows" {
+			Expect(files[0].Mode().Perm()).To(Equal(os.FileMode(0600)))
+		}
+	})
+
+	It("removes a previously written key when none is configured", func() {
+		Expect((&GitDriver{PrivateKey: "old-key"}).setUpAuth()).To(Succeed())
+		Expect((&GitDriver{}).setUpAuth()).To(Succeed())
+
+		_, err := os.Stat(privateKeyPath)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		_, set := os.LookupEnv("GIT_SSH_COMMAND")
+		Expect(set).To(BeFalse())
+	})
+
+	It("rewrites the netrc every time", func() {
+		Expect((&GitDriver{Username: "user", Password: "old-password"}).setUpAuth()).To(Succeed())
+		Expect((&GitDriver{Username: "user", Password: "new-password"}).setUpAuth()).To(Succeed())
+		Expect(ioutil.ReadFile(netRcPath)).To(Equal([]byte("default login user password new-password")))
+	})
+})
@@ -0,0 +1,28 @@
+package driver
+
+import (
+	"os"
+	"os/exec"
+)
+
+// curl, and therefore git, looks for _netrc rather than .netrc on Windows.
+const netRcFileName = "_netrc"
+
+func homeDir() string {
+	home := os.Getenv("HOME")
+	if home != "" {
+		return home
+	}
+
+	return os.Getenv("USERPROFILE")
+}
+
+// restrictToOwner replaces the inherited ACL of path with one granting access
+// only to the current user. File modes are ignored on Windows, and OpenSSH
+// refuses to use a private key that other users can read.
+func restrictToOwner(path string) error {
+	icacls := exec.Command("icacls", path, "/inheritance:r", "/grant:r", os.Getenv("USERNAME")+":F")
+	icacls.Stdout = os.Stderr
+	icacls.Stderr = os.Stderr
+	return icacls.Run()
+}
@@ -0,0 +1,36 @@
+package driver
+
+import "time"
+
+// A Guard checks a change just before it is written, returning an error to
+// refuse it. Drivers that retry after losing a race check the change they
+// retry with again, so the change allowed is always the one written.
+type Guard func(Change) error
+
+// checkChange has every guard check change, once lastModified has filled in
+// when the version it replaces was written.
+func checkChange(guards []Guard, change Change, lastModified func() (time.Time, bool, error)) error {
+	if len(guards) == 0 {
+		return nil
+	}
+
+	if change.From != nil {
+		modified, found, err := lastModified()
+		if err != nil {
+			return err
+		}
+
+		if found {
+			change.Modified = &modified
+		}
+	}
+
+	for _, guard := range guards {
+		err := guard(change)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
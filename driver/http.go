@@ -0,0 +1,25 @@
+package driver
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPClient is shared by every backend and API client constructed in this
+// process, so that connections are pooled and kept alive across requests,
+// SDK retries and compare-and-swap iterations instead of being re-dialed.
+var HTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+}
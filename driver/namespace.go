@@ -0,0 +1,63 @@
+package driver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// Namespaced returns source with the version, and everything stored next to
+// it, moved under its namespace, e.g. the key version becomes
+// payments/api/version. It must be applied once, before the source is used.
+func Namespaced(source models.Source) (models.Source, error) {
+	if source.Namespace == "" {
+		return source, nil
+	}
+
+	err := ValidateNamespace(source.Namespace)
+	if err != nil {
+		return source, err
+	}
+
+	within := func(name string) string {
+		if name == "" {
+			return ""
+		}
+
+		return source.Namespace + "/" + name
+	}
+
+	switch source.Driver {
+	case models.DriverGit:
+		source.File = within(source.File)
+	case models.DriverSwift:
+		source.OpenStack.ItemName = within(source.OpenStack.ItemName)
+	default:
+		source.Key = within(source.Key)
+	}
+
+	source.AuditLog = within(source.AuditLog)
+	source.Changelog.File = within(source.Changelog.File)
+	source.Signing.Name = within(source.Signing.Name)
+	source.Provenance.Name = within(source.Provenance.Name)
+
+	return source, nil
+}
+
+// ValidateNamespace checks that namespace is a relative path of one or more
+// segments, like payments/api.
+func ValidateNamespace(namespace string) error {
+	if path.IsAbs(namespace) || path.Clean(namespace) != namespace {
+		return models.ValidationError{fmt.Sprintf("invalid namespace (%s): must be a relative path like team/project", namespace)}
+	}
+
+	for _, segment := range strings.Split(namespace, "/") {
+		if segment == "." || segment == ".." || strings.Contains(segment, "\\") {
+			return models.ValidationError{fmt.Sprintf("invalid namespace (%s): must be a relative path like team/project", namespace)}
+		}
+	}
+
+	return nil
+}
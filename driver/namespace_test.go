@@ -0,0 +1,66 @@
+package driver_test
+
+import (
+	"github.com/concourse/semver-resource/driver"
+	"github.com/concourse/semver-resource/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Namespaced", func() {
+	It("leaves sources without a namespace alone", func() {
+		source := models.Source{Driver: models.DriverS3, Key: "version", AuditLog: "audit.jsonl"}
+
+		namespaced, err := driver.Namespaced(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaced).To(Equal(source))
+	})
+
+	It("moves the s3 key under the namespace", func() {
+		namespaced, err := driver.Namespaced(models.Source{Namespace: "payments/api", Key: "version"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaced.Key).To(Equal("payments/api/version"))
+		Expect(driver.Location(namespaced)).To(Equal("s3:///payments/api/version"))
+	})
+
+	It("moves the git file under the namespace", func() {
+		namespaced, err := driver.Namespaced(models.Source{Driver: models.DriverGit, Namespace: "payments", File: "version"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaced.File).To(Equal("payments/version"))
+	})
+
+	It("moves the swift item under the namespace", func() {
+		source := models.Source{Driver: models.DriverSwift, Namespace: "payments"}
+		source.OpenStack.ItemName = "version"
+
+		namespaced, err := driver.Namespaced(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaced.OpenStack.ItemName).To(Equal("payments/version"))
+	})
+
+	It("moves everything stored next to the version under the namespace", func() {
+		source := models.Source{
+			Driver:    models.DriverGit,
+			Namespace: "payments",
+			File:      "version",
+			AuditLog:  "audit.jsonl",
+		}
+		source.Changelog.File = "CHANGELOG.md"
+		source.Signing.Name = "version.sig"
+
+		namespaced, err := driver.Namespaced(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespaced.AuditLog).To(Equal("payments/audit.jsonl"))
+		Expect(namespaced.Changelog.File).To(Equal("payments/CHANGELOG.md"))
+		Expect(namespaced.Signing.Name).To(Equal("payments/version.sig"))
+		Expect(namespaced.Provenance.Name).To(BeEmpty())
+	})
+
+	It("rejects namespaces that are not relative paths", func() {
+		for _, namespace := range []string{"/payments", "payments/", "payments//api", "../payments", "payments/./api", `payments\api`} {
+			_, err := driver.Namespaced(models.Source{Namespace: namespace, Key: "version"})
+			Expect(err).To(HaveOccurred(), namespace)
+		}
+	})
+})
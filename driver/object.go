@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"time"
+
+	"github.com/blang/semver"
+)
+
+// objectStore is implemented by the drivers keeping the version and each of
+// its attachments in an object of their own, i.e. S3 and Swift.
+type objectStore interface {
+	readVersion() (semver.Version, bool, error)
+	LastModified() (time.Time, bool, error)
+	ReadAttachment(name string) ([]byte, error)
+	put(name string, content []byte) error
+}
+
+// objectWriter writes changes to the version in key, and its attachments, to
+// an object store.
+type objectWriter struct {
+	store objectStore
+	key   string
+
+	attachments []Attachment
+	guards      []Guard
+}
+
+func (writer objectWriter) set(to semver.Version) error {
+	change, err := setChange(writer.attachments, writer.guards, writer.store.readVersion, to)
+	if err != nil {
+		return err
+	}
+
+	return writer.write(change)
+}
+
+// write has every guard check the change, then puts the new version followed
+// by its attachments. Object stores have no transactions, so a failure can
+// leave the version written without them, and attachments are rendered from
+// their previous content and put back without checking it is unchanged, so a
+// concurrent write can be lost.
+func (writer objectWriter) write(change Change) error {
+	err := checkChange(writer.guards, change, writer.store.LastModified)
+	if err != nil {
+		return err
+	}
+
+	err = writer.store.put(writer.key, []byte(change.To.String()))
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range writer.attachments {
+		previous, err := writer.store.ReadAttachment(attachment.Name)
+		if err != nil {
+			return err
+		}
+
+		content, err := attachment.Render(previous, change)
+		if err != nil {
+			return err
+		}
+
+		err = writer.store.put(attachment.Name, content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
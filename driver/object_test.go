@@ -0,0 +1,110 @@
+package driver
+
+import (
+	"errors"
+	"time"
+
+	"github.com/blang/semver"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeObjectStore struct {
+	objects  map[string][]byte
+	modified time.Time
+	reads    int
+}
+
+func (store *fakeObjectStore) readVersion() (semver.Version, bool, error) {
+	store.reads++
+
+	content, found := store.objects["version"]
+	if !found {
+		return semver.Version{}, false, nil
+	}
+
+	current, err := semver.Parse(string(content))
+	if err != nil {
+		return semver.Version{}, false, invalidVersion{err}
+	}
+
+	return current, true, nil
+}
+
+func (store *fakeObjectStore) LastModified() (time.Time, bool, error) {
+	_, found := store.objects["version"]
+	return store.modified, found, nil
+}
+
+func (store *fakeObjectStore) ReadAttachment(name string) ([]byte, error) {
+	return store.objects[name], nil
+}
+
+func (store *fakeObjectStore) put(name string, content []byte) error {
+	store.objects[name] = content
+	return nil
+}
+
+var _ = Describe("Object stores", func() {
+	var store *fakeObjectStore
+	var writer objectWriter
+
+	BeforeEach(func() {
+		store = &fakeObjectStore{
+			objects:  map[string][]byte{"version": []byte("1.2.3")},
+			modified: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+		}
+
+		writer = objectWriter{store: store, key: "version"}
+	})
+
+	It("sets the version without reading it when nothing cares about it", func() {
+		Expect(writer.set(semver.Version{Major: 2})).To(Succeed())
+
+		Expect(string(store.objects["version"])).To(Equal("2.0.0"))
+		Expect(store.reads).To(BeZero())
+	})
+
+	It("renders attachments from their previous content after the version", func() {
+		store.objects["log"] = []byte("1.2.3\n")
+
+		writer.attachments = []Attachment{{
+			Name: "log",
+			Render: func(previous []byte, change Change) ([]byte, error) {
+				Expect(string(store.objects["version"])).To(Equal("2.0.0"))
+				return append(previous, change.From.String()+" -> "+change.To.String()+"\n"...), nil
+			},
+		}}
+
+		Expect(writer.set(semver.Version{Major: 2})).To(Succeed())
+
+		Expect(string(store.objects["log"])).To(Equal("1.2.3\n1.2.3 -> 2.0.0\n"))
+	})
+
+	It("writes nothing when a guard refuses the change", func() {
+		var checked Change
+		writer.guards = []Guard{func(change Change) error {
+			checked = change
+			return errors.New("not now")
+		}}
+
+		Expect(writer.set(semver.Version{Major: 2})).To(MatchError("not now"))
+
+		Expect(string(store.objects["version"])).To(Equal("1.2.3"))
+		Expect(checked.From).To(Equal(&semver.Version{Major: 1, Minor: 2, Patch: 3}))
+		Expect(checked.Modified).To(Equal(&store.modified))
+	})
+
+	It("sets over an invalid version", func() {
+		store.objects["version"] = []byte("bogus")
+		writer.guards = []Guard{func(change Change) error {
+			Expect(change.From).To(BeNil())
+			return nil
+		}}
+
+		Expect(writer.set(semver.Version{Major: 2})).To(Succeed())
+
+		Expect(string(store.objects["version"])).To(Equal("2.0.0"))
+	})
+})
@@ -0,0 +1,130 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/version"
+)
+
+// Reserver is implemented by drivers that can hand out versions to parallel
+// builds without publishing them, which requires an atomic write.
+type Reserver interface {
+	// Reserve allocates the version bump makes from the current one, or the
+	// next one after it that is not already reserved.
+	Reserve(version.Bump) (semver.Version, error)
+
+	// Commit publishes a reserved version, unless a later one is already
+	// current, and releases the reservation. published is false if the
+	// reservation was only released.
+	Commit(semver.Version) (published bool, err error)
+
+	// Abandon releases a reservation without publishing it.
+	Abandon(semver.Version) error
+}
+
+// ReservationsName is the name of the file reservations of the version stored
+// as versionName are kept in.
+func ReservationsName(versionName string) string {
+	return versionName + ".reservations"
+}
+
+// NotReserved is returned when committing or abandoning a version that is not
+// reserved, e.g. because it has already been committed.
+type NotReserved struct {
+	Version semver.Version
+}
+
+func (err NotReserved) Error() string {
+	return fmt.Sprintf("version %s is not reserved", err.Version)
+}
+
+// Reserved is returned when setting a version that is reserved, which only
+// the build holding the reservation may publish, by committing it.
+type Reserved struct {
+	Version semver.Version
+}
+
+func (err Reserved) Error() string {
+	return fmt.Sprintf("version %s is reserved and can only be committed", err.Version)
+}
+
+func parseReservations(content []byte) ([]semver.Version, error) {
+	reservations := []semver.Version{}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		reserved, err := semver.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reservation (%s): %s", line, err)
+		}
+
+		reservations = append(reservations, reserved)
+	}
+
+	return reservations, nil
+}
+
+func formatReservations(reservations []semver.Version) []byte {
+	content := ""
+	for _, reserved := range reservations {
+		content += reserved.String() + "\n"
+	}
+
+	return []byte(content)
+}
+
+// nextReservation is the version bump makes from current, advanced past any
+// that are reserved: to the next prerelease if bump makes one, so that parallel
+// builds get 1.3.0-rc.1, 1.3.0-rc.2 and so on, or by bumping again otherwise.
+func nextReservation(current semver.Version, reservations []semver.Version, bump version.Bump) (semver.Version, error) {
+	// advancing must not overwrite the version a recording bump was applied to
+	advance := bump
+	if recording, ok := bump.(*version.RecordingBump); ok {
+		advance = recording.Bump
+	}
+
+	if multi, ok := advance.(version.MultiBump); ok && len(multi) > 0 {
+		if pre, ok := multi[len(multi)-1].(version.PreBump); ok {
+			advance = pre
+		}
+	}
+
+	next := bump.Apply(current)
+	for isReserved(reservations, next) {
+		advanced := advance.Apply(next)
+		if !advanced.GT(next) {
+			return semver.Version{}, fmt.Errorf("version %s is already reserved", next)
+		}
+
+		next = advanced
+	}
+
+	return next, nil
+}
+
+func isReserved(reservations []semver.Version, version semver.Version) bool {
+	for _, reserved := range reservations {
+		if reserved.Equals(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// release removes version from reservations, or returns NotReserved.
+func release(reservations []semver.Version, version semver.Version) ([]semver.Version, error) {
+	for i, reserved := range reservations {
+		if reserved.Equals(version) {
+			return append(reservations[:i:i], reservations[i+1:]...), nil
+		}
+	}
+
+	return nil, NotReserved{Version: version}
+}
@@ -0,0 +1,90 @@
+package driver
+
+import (
+	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/version"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reservations", func() {
+	mustParse := func(str string) semver.Version {
+		parsed, err := semver.Parse(str)
+		Expect(err).NotTo(HaveOccurred())
+		return parsed
+	}
+
+	versions := func(strs ...string) []semver.Version {
+		parsed := []semver.Version{}
+		for _, str := range strs {
+			parsed = append(parsed, mustParse(str))
+		}
+
+		return parsed
+	}
+
+	It("round-trips the reservations file", func() {
+		reservations := versions("1.2.4-rc.1", "1.2.4-rc.2")
+
+		content := formatReservations(reservations)
+		Expect(string(content)).To(Equal("1.2.4-rc.1\n1.2.4-rc.2\n"))
+		Expect(parseReservations(content)).To(Equal(reservations))
+	})
+
+	It("reads a missing file as no reservations", func() {
+		Expect(parseReservations(nil)).To(BeEmpty())
+	})
+
+	It("rejects invalid reservations", func() {
+		_, err := parseReservations([]byte("1.2.4\nbogus\n"))
+		Expect(err).To(MatchError(HavePrefix("invalid reservation (bogus)")))
+	})
+
+	next := func(current string, reservations []semver.Version, bump version.Bump) string {
+		reserved, err := nextReservation(mustParse(current), reservations, bump)
+		Expect(err).NotTo(HaveOccurred())
+		return reserved.String()
+	}
+
+	It("bumps the current version when nothing is reserved", func() {
+		Expect(next("1.2.3", nil, version.PatchBump{})).To(Equal("1.2.4"))
+	})
+
+	It("bumps again past reserved versions", func() {
+		Expect(next("1.2.3", versions("1.2.4", "1.2.5"), version.PatchBump{})).To(Equal("1.2.6"))
+		Expect(next("1.2.3", versions("1.2.5"), version.PatchBump{})).To(Equal("1.2.4"))
+	})
+
+	It("advances the prerelease past reserved versions", func() {
+		bump := version.BumpFromParams("minor", "rc")
+
+		Expect(next("1.2.3", nil, bump)).To(Equal("1.3.0-rc.1"))
+		Expect(next("1.2.3", versions("1.3.0-rc.1", "1.3.0-rc.2"), bump)).To(Equal("1.3.0-rc.3"))
+		Expect(next("1.3.0-rc.1", versions("1.3.0-rc.2"), version.BumpFromParams("", "rc"))).To(Equal("1.3.0-rc.3"))
+	})
+
+	It("advances recording bumps like the bump they record", func() {
+		bump := &version.RecordingBump{Bump: version.BumpFromParams("patch", "rc")}
+
+		Expect(next("1.2.3", versions("1.2.4-rc.1"), bump)).To(Equal("1.2.4-rc.2"))
+		Expect(bump.Applied.String()).To(Equal("1.2.3"))
+	})
+
+	It("fails when the version cannot be advanced", func() {
+		_, err := nextReservation(mustParse("1.3.0-rc.2"), versions("1.3.0"), version.BumpFromParams("final", ""))
+		Expect(err).To(MatchError("version 1.3.0 is already reserved"))
+	})
+
+	It("releases reservations without modifying the original", func() {
+		reservations := versions("1.2.4", "1.2.5", "1.2.6")
+
+		remaining, err := release(reservations, mustParse("1.2.5"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remaining).To(Equal(versions("1.2.4", "1.2.6")))
+		Expect(reservations).To(Equal(versions("1.2.4", "1.2.5", "1.2.6")))
+
+		_, err = release(reservations, mustParse("1.2.7"))
+		Expect(err).To(MatchError("version 1.2.7 is not reserved"))
+	})
+})
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/tracing"
 	"github.com/concourse/semver-resource/version"
 )
 
@@ -18,35 +20,24 @@ type S3Driver struct {
 	Svc        *s3.S3
 	BucketName string
 	Key        string
+
+	Attachments []Attachment
+	Guards      []Guard
 }
 
 func (driver *S3Driver) Bump(bump version.Bump) (semver.Version, error) {
-	var currentVersion semver.Version
-
-	resp, err := driver.Svc.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(driver.BucketName),
-		Key:    aws.String(driver.Key),
-	})
-	if err == nil {
-		bucketNumberPayload, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return semver.Version{}, err
-		}
-		defer resp.Body.Close()
+	currentVersion, exists, err := driver.readVersion()
+	if err != nil {
+		return semver.Version{}, err
+	}
 
-		currentVersion, err = semver.Parse(string(bucketNumberPayload))
-		if err != nil {
-			return semver.Version{}, err
-		}
-	} else if s3err, ok := err.(awserr.RequestFailure); ok && s3err.StatusCode() == 404 {
+	if !exists {
 		currentVersion = driver.InitialVersion
-	} else {
-		return semver.Version{}, err
 	}
 
 	newVersion := bump.Apply(currentVersion)
 
-	err = driver.Set(newVersion)
+	err = driver.objects().write(changeFrom(currentVersion, exists, newVersion, bump.String()))
 	if err != nil {
 		return semver.Version{}, err
 	}
@@ -55,25 +46,66 @@ func (driver *S3Driver) Bump(bump version.Bump) (semver.Version, error) {
 }
 
 func (driver *S3Driver) Set(newVersion semver.Version) error {
-	params := &s3.PutObjectInput{
-		Bucket:      aws.String(driver.BucketName),
-		Key:         aws.String(driver.Key),
-		ContentType: aws.String("text/plain"),
-		Body:        bytes.NewReader([]byte(newVersion.String())),
-		ACL:         aws.String(s3.ObjectCannedACLPrivate),
+	return driver.objects().set(newVersion)
+}
+
+// Attach adds an attachment to be written along with every change.
+func (driver *S3Driver) Attach(attachment Attachment) {
+	driver.Attachments = append(driver.Attachments, attachment)
+}
+
+// Guard adds a guard to check every change with before it is written.
+func (driver *S3Driver) Guard(guard Guard) {
+	driver.Guards = append(driver.Guards, guard)
+}
+
+func (driver *S3Driver) ReadAttachment(name string) ([]byte, error) {
+	resp, err := driver.getObject(name)
+	if err == nil {
+		defer resp.Body.Close()
+		return ioutil.ReadAll(resp.Body)
+	} else if s3err, ok := err.(awserr.RequestFailure); ok && s3err.StatusCode() == 404 {
+		return nil, nil
+	} else {
+		return nil, err
 	}
+}
 
-	_, err := driver.Svc.PutObject(params)
-	return err
+func (driver *S3Driver) readVersion() (semver.Version, bool, error) {
+	resp, err := driver.getObject(driver.Key)
+	if err == nil {
+		bucketNumberPayload, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return semver.Version{}, false, err
+		}
+		defer resp.Body.Close()
+
+		currentVersion, err := semver.Parse(string(bucketNumberPayload))
+		if err != nil {
+			return semver.Version{}, false, invalidVersion{err}
+		}
+
+		return currentVersion, true, nil
+	} else if s3err, ok := err.(awserr.RequestFailure); ok && s3err.StatusCode() == 404 {
+		return semver.Version{}, false, nil
+	} else {
+		return semver.Version{}, false, err
+	}
+}
+
+func (driver *S3Driver) objects() objectWriter {
+	return objectWriter{
+		store:       driver,
+		key:         driver.Key,
+		attachments: driver.Attachments,
+		guards:      driver.Guards,
+	}
 }
 
 func (driver *S3Driver) Check(cursor *semver.Version) ([]semver.Version, error) {
 	var bucketNumber string
 
-	resp, err := driver.Svc.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(driver.BucketName),
-		Key:    aws.String(driver.Key),
-	})
+	resp, err := driver.getObject(driver.Key)
 	if err == nil {
 		bucketNumberPayload, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
@@ -103,3 +135,59 @@ func (driver *S3Driver) Check(cursor *semver.Version) ([]semver.Version, error)
 
 	return []semver.Version{}, nil
 }
+
+func (driver *S3Driver) LastModified() (time.Time, bool, error) {
+	span := driver.startSpan("s3.head", driver.Key)
+
+	resp, err := driver.Svc.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(driver.BucketName),
+		Key:    aws.String(driver.Key),
+	})
+	span.End(err)
+
+	if s3err, ok := err.(awserr.RequestFailure); ok && s3err.StatusCode() == 404 {
+		return time.Time{}, false, nil
+	}
+
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return aws.TimeValue(resp.LastModified), true, nil
+}
+
+func (driver *S3Driver) getObject(key string) (*s3.GetObjectOutput, error) {
+	span := driver.startSpan("s3.get", key)
+
+	resp, err := driver.Svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(driver.BucketName),
+		Key:    aws.String(key),
+	})
+	span.End(err)
+
+	return resp, err
+}
+
+func (driver *S3Driver) put(key string, content []byte) error {
+	params := &s3.PutObjectInput{
+		Bucket:      aws.String(driver.BucketName),
+		Key:         aws.String(key),
+		ContentType: aws.String("text/plain"),
+		Body:        bytes.NewReader(content),
+		ACL:         aws.String(s3.ObjectCannedACLPrivate),
+	}
+
+	span := driver.startSpan("s3.put", key)
+
+	_, err := driver.Svc.PutObject(params)
+	span.End(err)
+
+	return err
+}
+
+func (driver *S3Driver) startSpan(name string, key string) *tracing.Span {
+	span := tracing.Start(name)
+	span.SetAttribute("s3.bucket", driver.BucketName)
+	span.SetAttribute("s3.key", key)
+	return span
+}
@@ -0,0 +1,139 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/private/signer/v4"
+)
+
+// SecretsManager is a minimal AWS Secrets Manager client, speaking its JSON
+// protocol over the SDK's request pipeline so that credentials are found and
+// requests signed the same way as for S3, e.g. using the worker's IAM role.
+type SecretsManager struct {
+	*awsclient.Client
+}
+
+const secretsManagerServiceName = "secretsmanager"
+
+func NewSecretsManager(cfgs ...*aws.Config) *SecretsManager {
+	config := session.New(&aws.Config{
+		HTTPClient: HTTPClient,
+		MaxRetries: aws.Int(maxRetries),
+	}).ClientConfig(secretsManagerServiceName, cfgs...)
+
+	svc := &SecretsManager{
+		Client: awsclient.New(
+			*config.Config,
+			metadata.ClientInfo{
+				ServiceName:   secretsManagerServiceName,
+				SigningRegion: config.SigningRegion,
+				Endpoint:      config.Endpoint,
+				APIVersion:    "2017-10-17",
+				JSONVersion:   "1.1",
+				TargetPrefix:  "secretsmanager",
+			},
+			config.Handlers,
+		),
+	}
+
+	svc.Handlers.Sign.PushBack(v4.Sign)
+	svc.Handlers.Build.PushBack(buildJSONRequest)
+	svc.Handlers.Unmarshal.PushBack(unmarshalJSONResponse)
+	svc.Handlers.UnmarshalError.PushBack(unmarshalJSONError)
+
+	return svc
+}
+
+type getSecretValueInput struct {
+	SecretId string
+}
+
+type getSecretValueOutput struct {
+	SecretString string
+	SecretBinary []byte
+}
+
+// GetSecretValue returns the current value of the secret with the given name
+// or ARN.
+func (svc *SecretsManager) GetSecretValue(secretID string) ([]byte, error) {
+	output := &getSecretValueOutput{}
+
+	req := svc.NewRequest(&request.Operation{
+		Name:       "GetSecretValue",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, &getSecretValueInput{SecretId: secretID}, output)
+
+	err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+
+	if output.SecretString != "" {
+		return []byte(output.SecretString), nil
+	}
+
+	return output.SecretBinary, nil
+}
+
+func buildJSONRequest(r *request.Request) {
+	body, err := json.Marshal(r.Params)
+	if err != nil {
+		r.Error = awserr.New("SerializationError", "failed encoding JSON request", err)
+		return
+	}
+
+	r.SetBufferBody(body)
+	r.HTTPRequest.Header.Set("X-Amz-Target", r.ClientInfo.TargetPrefix+"."+r.Operation.Name)
+	r.HTTPRequest.Header.Set("Content-Type", "application/x-amz-json-"+r.ClientInfo.JSONVersion)
+}
+
+func unmarshalJSONResponse(r *request.Request) {
+	defer r.HTTPResponse.Body.Close()
+
+	err := json.NewDecoder(r.HTTPResponse.Body).Decode(r.Data)
+	if err != nil {
+		r.Error = awserr.New("SerializationError", "failed decoding JSON response", err)
+	}
+}
+
+func unmarshalJSONError(r *request.Request) {
+	defer r.HTTPResponse.Body.Close()
+
+	var body bytes.Buffer
+	body.ReadFrom(r.HTTPResponse.Body)
+
+	var failure struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+
+	err := json.Unmarshal(body.Bytes(), &failure)
+	if err != nil {
+		r.Error = awserr.NewRequestFailure(
+			awserr.New("SerializationError", body.String(), err),
+			r.HTTPResponse.StatusCode,
+			r.RequestID,
+		)
+		return
+	}
+
+	// error types may be qualified, e.g. "namespace#ResourceNotFoundException"
+	code := failure.Type
+	if i := bytes.LastIndexByte([]byte(code), '#'); i != -1 {
+		code = code[i+1:]
+	}
+
+	r.Error = awserr.NewRequestFailure(
+		awserr.New(code, failure.Message, nil),
+		r.HTTPResponse.StatusCode,
+		r.RequestID,
+	)
+}
@@ -0,0 +1,108 @@
+package driver
+
+import (
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/signing"
+)
+
+// Signatures are attachments storing a signed payload describing every
+// change: the payload itself as name.json, its signature as name.sig and,
+// when signing keylessly, the signing certificate as name.pem.
+func Signatures(name string, signer signing.Signer) []Attachment {
+	signed := &signedChange{signer: signer}
+
+	attachments := []Attachment{
+		{
+			Name: name + ".json",
+			Render: func(previous []byte, change Change) ([]byte, error) {
+				return signed.payloadFor(change)
+			},
+		},
+		{
+			Name: name + ".sig",
+			Render: func(previous []byte, change Change) ([]byte, error) {
+				signature, err := signed.signatureFor(change)
+				return signature.Signature, err
+			},
+		},
+	}
+
+	if _, keyless := signer.(*signing.KeylessSigner); keyless {
+		attachments = append(attachments, Attachment{
+			Name: name + ".pem",
+			Render: func(previous []byte, change Change) ([]byte, error) {
+				signature, err := signed.signatureFor(change)
+				return signature.Certificate, err
+			},
+		})
+	}
+
+	return attachments
+}
+
+// signedChange signs each change once, however many of its attachments are
+// rendered.
+type signedChange struct {
+	signer signing.Signer
+
+	change    *Change
+	payload   []byte
+	signature signing.Signature
+}
+
+func (signed *signedChange) payloadFor(change Change) ([]byte, error) {
+	err := signed.sign(change)
+	return signed.payload, err
+}
+
+func (signed *signedChange) signatureFor(change Change) (signing.Signature, error) {
+	err := signed.sign(change)
+	return signed.signature, err
+}
+
+func (signed *signedChange) sign(change Change) error {
+	if signed.change != nil && sameChange(*signed.change, change) {
+		return nil
+	}
+
+	payload := signing.Payload{
+		Version:   change.To.String(),
+		Bump:      change.Bump,
+		Build:     models.BuildMetadataFromEnv(),
+		Timestamp: time.Now().UTC(),
+	}
+
+	if change.From != nil {
+		payload.Previous = change.From.String()
+	}
+
+	marshalled, err := payload.Marshal()
+	if err != nil {
+		return err
+	}
+
+	signature, err := signed.signer.Sign(marshalled)
+	if err != nil {
+		return err
+	}
+
+	signed.change = &change
+	signed.payload = marshalled
+	signed.signature = signature
+
+	return nil
+}
+
+func sameChange(a Change, b Change) bool {
+	if (a.From == nil) != (b.From == nil) {
+		return false
+	}
+
+	if a.From != nil && !a.From.Equals(*b.From) {
+		return false
+	}
+
+	return a.To.Equals(b.To) && a.Bump == b.Bump
+}
@@ -0,0 +1,80 @@
+package driver_test
+
+import (
+	"encoding/json"
+
+	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/driver"
+	"github.com/concourse/semver-resource/signing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeSigner struct {
+	signed [][]byte
+}
+
+func (signer *fakeSigner) Sign(payload []byte) (signing.Signature, error) {
+	signer.signed = append(signer.signed, payload)
+	return signing.Signature{Signature: []byte("signature")}, nil
+}
+
+var _ = Describe("Signatures", func() {
+	var signer *fakeSigner
+	var attachments []driver.Attachment
+	var change driver.Change
+
+	BeforeEach(func() {
+		signer = &fakeSigner{}
+		attachments = driver.Signatures("version", signer)
+
+		from := semver.Version{Major: 1, Minor: 4, Patch: 2}
+		change = driver.Change{
+			From: &from,
+			To:   semver.Version{Major: 1, Minor: 5},
+			Bump: "minor",
+		}
+	})
+
+	render := func(change driver.Change) map[string]string {
+		rendered := map[string]string{}
+		for _, attachment := range attachments {
+			content, err := attachment.Render(nil, change)
+			Expect(err).NotTo(HaveOccurred())
+			rendered[attachment.Name] = string(content)
+		}
+
+		return rendered
+	}
+
+	It("stores the signed payload and its signature", func() {
+		rendered := render(change)
+		Expect(rendered).To(HaveLen(2))
+		Expect(rendered["version.sig"]).To(Equal("signature"))
+
+		var payload signing.Payload
+		Expect(json.Unmarshal([]byte(rendered["version.json"]), &payload)).To(Succeed())
+		Expect(payload.Version).To(Equal("1.5.0"))
+		Expect(payload.Previous).To(Equal("1.4.2"))
+		Expect(payload.Bump).To(Equal("minor"))
+
+		Expect(signer.signed).To(Equal([][]byte{[]byte(rendered["version.json"])}))
+	})
+
+	It("signs again when the change is retried", func() {
+		render(change)
+
+		change.To = semver.Version{Major: 1, Minor: 6}
+		rendered := render(change)
+
+		Expect(signer.signed).To(HaveLen(2))
+		Expect(rendered["version.json"]).To(ContainSubstring(`"version": "1.6.0"`))
+	})
+
+	It("also stores the certificate when signing keylessly", func() {
+		attachments = driver.Signatures("version", signing.NewKeylessSigner("", "", "", nil))
+		Expect(attachments).To(HaveLen(3))
+		Expect(attachments[2].Name).To(Equal("version.pem"))
+	})
+})
@@ -1,11 +1,14 @@
 package driver
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/tracing"
 	"github.com/concourse/semver-resource/version"
 	"github.com/rackspace/gophercloud"
 	"github.com/rackspace/gophercloud/openstack"
@@ -17,6 +20,8 @@ type SwiftDriver struct {
 	Container          string
 	ItemName           string
 	InitialVersion     semver.Version
+	Attachments        []Attachment
+	Guards             []Guard
 	swiftServiceClient *gophercloud.ServiceClient
 }
 
@@ -34,21 +39,7 @@ func NewSwiftDriver(source *models.Source) (Driver, error) {
 		return nil, fmt.Errorf("openstack/item_name is empty but must be specified")
 	}
 
-	opts := gophercloud.AuthOptions{
-		IdentityEndpoint: os.IdentityEndpoint,
-		Username:         os.Username,
-		UserID:           os.UserID,
-		Password:         os.Password,
-		APIKey:           os.APIKey,
-		DomainID:         os.DomainID,
-		DomainName:       os.DomainName,
-		TenantID:         os.TenantID,
-		TenantName:       os.TenantName,
-		AllowReauth:      os.AllowReauth,
-		TokenID:          os.TokenID,
-	}
-
-	swiftServiceClient, err := getSwiftClient(opts, os.Region)
+	swiftServiceClient, err := getSwiftClient(createOpts(os), os.Region)
 	if err != nil {
 		return nil, err
 	}
@@ -63,18 +54,31 @@ func NewSwiftDriver(source *models.Source) (Driver, error) {
 		return nil, fmt.Errorf("Initial version was not a valid sem ver: %s", err.Error())
 	}
 
+	attachments, err := attachmentsFor(*source)
+	if err != nil {
+		return nil, err
+	}
+
 	driver := &SwiftDriver{
 		swiftServiceClient: swiftServiceClient,
 		InitialVersion:     initialVersion,
 		Container:          source.OpenStack.Container,
 		ItemName:           source.OpenStack.ItemName,
+		Attachments:        attachments,
 	}
 
 	return driver, nil
 }
 
 func getSwiftClient(opts gophercloud.AuthOptions, region string) (*gophercloud.ServiceClient, error) {
-	provider, err := openstack.AuthenticatedClient(opts)
+	provider, err := openstack.NewClient(opts.IdentityEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to Authenticate, inner error: %s", err.Error())
+	}
+
+	provider.HTTPClient = *HTTPClient
+
+	err = openstack.Authenticate(provider, opts)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to Authenticate, inner error: %s", err.Error())
 	}
@@ -109,13 +113,17 @@ func createOpts(os models.OpenStackOptions) gophercloud.AuthOptions {
 }
 
 func (driver *SwiftDriver) Bump(bump version.Bump) (semver.Version, error) {
-	currentVersion, err := driver.getCurrentVersion()
+	currentVersion, exists, err := driver.readVersion()
 	if err != nil {
 		return semver.Version{}, err
 	}
 
+	if !exists {
+		currentVersion = driver.InitialVersion
+	}
+
 	newVersion := bump.Apply(currentVersion)
-	err = driver.Set(newVersion)
+	err = driver.objects().write(changeFrom(currentVersion, exists, newVersion, bump.String()))
 	if err != nil {
 		return semver.Version{}, err
 	}
@@ -124,17 +132,7 @@ func (driver *SwiftDriver) Bump(bump version.Bump) (semver.Version, error) {
 }
 
 func (driver *SwiftDriver) Set(newVersion semver.Version) error {
-	content := strings.NewReader(newVersion.String())
-	opts := objects.CreateOpts{
-		ContentDisposition: fmt.Sprintf(`attachment; filename="%s"`, driver.ItemName),
-	}
-
-	// Now execute the upload
-	res := objects.Create(driver.swiftServiceClient, driver.Container, driver.ItemName, content, opts)
-
-	// We have the option of extracting the resulting headers from the response
-	_, err := res.ExtractHeader()
-	return err
+	return driver.objects().set(newVersion)
 }
 
 func (driver *SwiftDriver) Check(cursor *semver.Version) ([]semver.Version, error) {
@@ -150,22 +148,118 @@ func (driver *SwiftDriver) Check(cursor *semver.Version) ([]semver.Version, erro
 	return []semver.Version{}, nil
 }
 
-func (driver *SwiftDriver) getCurrentVersion() (semver.Version, error) {
-	bytes, err := objects.Download(driver.swiftServiceClient, driver.Container, driver.ItemName, nil).ExtractContent()
-	unexpectedResponseCodeError, isType := err.(*gophercloud.UnexpectedResponseCodeError)
-	if isType && unexpectedResponseCodeError.Actual == 404 {
-		return driver.InitialVersion, nil
+// Attach adds an attachment to be written along with every change.
+func (driver *SwiftDriver) Attach(attachment Attachment) {
+	driver.Attachments = append(driver.Attachments, attachment)
+}
+
+// Guard adds a guard to check every change with before it is written.
+func (driver *SwiftDriver) Guard(guard Guard) {
+	driver.Guards = append(driver.Guards, guard)
+}
+
+func (driver *SwiftDriver) ReadAttachment(name string) ([]byte, error) {
+	content, found, err := driver.download(name)
+	if !found {
+		return nil, err
 	}
 
+	return content, err
+}
+
+func (driver *SwiftDriver) getCurrentVersion() (semver.Version, error) {
+	itemVersion, exists, err := driver.readVersion()
 	if err != nil {
 		return semver.Version{}, err
 	}
 
+	if !exists {
+		return driver.InitialVersion, nil
+	}
+
+	return itemVersion, nil
+}
+
+func (driver *SwiftDriver) readVersion() (semver.Version, bool, error) {
+	bytes, found, err := driver.download(driver.ItemName)
+	if !found || err != nil {
+		return semver.Version{}, false, err
+	}
+
 	value := strings.TrimSpace(string(bytes))
 	itemVersion, err := semver.Parse(value)
 	if err != nil {
-		return semver.Version{}, fmt.Errorf("parsing number in container: %s", err)
+		return semver.Version{}, false, invalidVersion{fmt.Errorf("parsing number in container: %s", err)}
 	}
 
-	return itemVersion, nil
+	return itemVersion, true, nil
+}
+
+func (driver *SwiftDriver) objects() objectWriter {
+	return objectWriter{
+		store:       driver,
+		key:         driver.ItemName,
+		attachments: driver.Attachments,
+		guards:      driver.Guards,
+	}
+}
+
+func (driver *SwiftDriver) LastModified() (time.Time, bool, error) {
+	span := driver.startSpan("swift.head", driver.ItemName)
+
+	header, err := objects.Get(driver.swiftServiceClient, driver.Container, driver.ItemName, nil).Extract()
+	span.End(err)
+
+	unexpectedResponseCodeError, isType := err.(*gophercloud.UnexpectedResponseCodeError)
+	if isType && unexpectedResponseCodeError.Actual == 404 {
+		return time.Time{}, false, nil
+	}
+
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return header.LastModified, true, nil
+}
+
+func (driver *SwiftDriver) download(name string) ([]byte, bool, error) {
+	span := driver.startSpan("swift.get", name)
+
+	bytes, err := objects.Download(driver.swiftServiceClient, driver.Container, name, nil).ExtractContent()
+	span.End(err)
+
+	unexpectedResponseCodeError, isType := err.(*gophercloud.UnexpectedResponseCodeError)
+	if isType && unexpectedResponseCodeError.Actual == 404 {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return bytes, true, nil
+}
+
+func (driver *SwiftDriver) put(name string, content []byte) error {
+	opts := objects.CreateOpts{
+		ContentDisposition: fmt.Sprintf(`attachment; filename="%s"`, name),
+	}
+
+	span := driver.startSpan("swift.put", name)
+
+	// Now execute the upload
+	res := objects.Create(driver.swiftServiceClient, driver.Container, name, bytes.NewReader(content), opts)
+
+	// We have the option of extracting the resulting headers from the response
+	_, err := res.ExtractHeader()
+	span.End(err)
+
+	return err
+}
+
+func (driver *SwiftDriver) startSpan(name string, itemName string) *tracing.Span {
+	span := tracing.Start(name)
+	span.SetAttribute("swift.container", driver.Container)
+	span.SetAttribute("swift.item_name", itemName)
+	return span
 }
@@ -0,0 +1,166 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// VaultClient reads secrets over Vault's HTTP API, authenticating with a
+// token or by logging in with AppRole.
+type VaultClient struct {
+	options models.VaultOptions
+	client  *http.Client
+}
+
+func NewVaultClient(options models.VaultOptions, client *http.Client) *VaultClient {
+	if options.URL == "" {
+		options.URL = os.Getenv("VAULT_ADDR")
+	}
+
+	if options.Token == "" && options.RoleID == "" {
+		options.Token = os.Getenv("VAULT_TOKEN")
+	}
+
+	if options.AuthMount == "" {
+		options.AuthMount = "approle"
+	}
+
+	return &VaultClient{
+		options: options,
+		client:  client,
+	}
+}
+
+type vaultResponse struct {
+	Data map[string]interface{} `json:"data"`
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// Read returns the string values of the secret at path. Secrets in a version 2
+// key/value engine are unwrapped.
+func (vault *VaultClient) Read(path string) (map[string]string, error) {
+	if vault.options.URL == "" {
+		return nil, fmt.Errorf("no vault url configured")
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("no secret path configured")
+	}
+
+	token := vault.options.Token
+	if token == "" {
+		var err error
+		token, err = vault.login()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var response vaultResponse
+	err := vault.do("GET", path, token, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	data := response.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	secret := map[string]string{}
+	for key, value := range data {
+		if str, ok := value.(string); ok {
+			secret[key] = str
+		}
+	}
+
+	return secret, nil
+}
+
+func (vault *VaultClient) login() (string, error) {
+	if vault.options.RoleID == "" {
+		return "", fmt.Errorf("no vault token or role_id configured")
+	}
+
+	var response vaultResponse
+	err := vault.do("POST", "auth/"+vault.options.AuthMount+"/login", "", map[string]string{
+		"role_id":   vault.options.RoleID,
+		"secret_id": vault.options.SecretID,
+	}, &response)
+	if err != nil {
+		return "", fmt.Errorf("logging in: %w", err)
+	}
+
+	return response.Auth.ClientToken, nil
+}
+
+func (vault *VaultClient) do(method string, path string, token string, body interface{}, response *vaultResponse) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	url := strings.TrimRight(vault.options.URL, "/") + "/v1/" + strings.TrimLeft(path, "/")
+
+	request, err := http.NewRequest(method, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		request.Header.Set("X-Vault-Token", token)
+	}
+
+	if vault.options.Namespace != "" {
+		request.Header.Set("X-Vault-Namespace", vault.options.Namespace)
+	}
+
+	resp, err := vault.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(response)
+
+	if resp.StatusCode/100 != 2 {
+		return VaultError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Errors:     response.Errors,
+		}
+	}
+
+	return err
+}
+
+// VaultError is an unsuccessful response from Vault.
+type VaultError struct {
+	StatusCode int
+	Status     string
+	Errors     []string
+}
+
+func (err VaultError) Error() string {
+	if len(err.Errors) > 0 {
+		return fmt.Sprintf("vault responded with %s: %s", err.Status, strings.Join(err.Errors, "; "))
+	}
+
+	return fmt.Sprintf("vault responded with %s", err.Status)
+}
@@ -3,12 +3,18 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/blang/semver"
 
+	"github.com/concourse/semver-resource/driver"
+	"github.com/concourse/semver-resource/metrics"
 	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/provenance"
+	"github.com/concourse/semver-resource/tracing"
 	"github.com/concourse/semver-resource/version"
 )
 
@@ -18,6 +24,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	start := time.Now()
+
 	destination := os.Args[1]
 
 	err := os.MkdirAll(destination, 0755)
@@ -31,6 +39,24 @@ func main() {
 		fatal("reading request", err)
 	}
 
+	requestSource = &request.Source
+
+	request.Source, err = driver.Namespaced(request.Source)
+	if err != nil {
+		fatal("resolving namespace", err)
+	}
+
+	err = models.Validate(request.Params)
+	if err != nil {
+		fatal("validating params", err)
+	}
+
+	recorder := metrics.FromSource(request.Source, driver.HTTPClient)
+
+	tracing.Configure(request.Source.Tracing, driver.HTTPClient)
+
+	span := tracing.Start("in")
+
 	inputVersion, err := semver.Parse(request.Version.Number)
 	if err != nil {
 		fatal("parsing semantic version", err)
@@ -58,15 +84,89 @@ func main() {
 		}
 	}
 
+	if request.Source.Provenance.Store {
+		err := writeProvenance(request, filepath.Join(destination, "provenance.json"))
+		if err != nil {
+			fatal("fetching provenance", err)
+		}
+	}
+
+	recorder.Since("get_duration", "Time taken to provide the version.", start)
+
+	err = recorder.Push()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	span.End(nil)
+
+	err = tracing.Flush()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
 	json.NewEncoder(os.Stdout).Encode(models.InResponse{
 		Version: request.Version,
 		Metadata: models.Metadata{
-			{"number", request.Version.Number},
+			{Name: "number", Value: request.Version.Number},
 		},
 	})
 }
 
+// writeProvenance writes the stored attestation to path, if it describes the
+// requested version. It won't if the version has been overwritten since.
+func writeProvenance(request models.InRequest, path string) error {
+	store, err := driver.FromSource(request.Source)
+	if err != nil {
+		return err
+	}
+
+	reader, ok := store.(driver.AttachmentReader)
+	if !ok {
+		return models.ValidationError{"driver cannot read a stored attestation"}
+	}
+
+	name := provenance.StoredName(request.Source.Provenance, driver.VersionName(request.Source))
+
+	attestation, err := reader.ReadAttachment(name)
+	if err != nil {
+		return err
+	}
+
+	if attestation == nil {
+		fmt.Fprintf(os.Stderr, "no provenance stored\n")
+		return nil
+	}
+
+	var statement provenance.Statement
+	err = json.Unmarshal(attestation, &statement)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %s", name, err)
+	}
+
+	if !statement.Describes(request.Version.Number) {
+		fmt.Fprintf(os.Stderr, "stored provenance is not for %s\n", request.Version.Number)
+		return nil
+	}
+
+	return ioutil.WriteFile(path, attestation, 0644)
+}
+
+// requestSource is the source failures are reported with, once the request
+// has been read.
+var requestSource *models.Source
+
+// fatal reports err, both as text and as a JSON failure on stdout for
+// tooling to branch on, and exits.
 func fatal(doing string, err error) {
-	println("error " + doing + ": " + err.Error())
+	tracing.Fail(err)
+
+	message := "error " + doing + ": " + err.Error()
+	println(message)
+
+	json.NewEncoder(os.Stdout).Encode(map[string]driver.Failure{
+		"error": driver.NewFailure("in", requestSource, message, err),
+	})
+
 	os.Exit(1)
 }
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+type Kind int
+
+const (
+	Counter Kind = iota
+	Gauge
+	Timer
+)
+
+// Metric is a single measurement taken during an operation. Sinks decide how
+// each kind is named and encoded.
+type Metric struct {
+	Name  string
+	Help  string
+	Kind  Kind
+	Value float64
+}
+
+type Sink interface {
+	Emit(labels map[string]string, metrics []Metric) error
+}
+
+// Recorder collects the metrics of one check, in or out and hands them to
+// every configured sink. Without sinks it discards them.
+type Recorder struct {
+	labels  map[string]string
+	sinks   []Sink
+	metrics []Metric
+}
+
+func NewRecorder(labels map[string]string, sinks ...Sink) *Recorder {
+	return &Recorder{
+		labels: labels,
+		sinks:  sinks,
+	}
+}
+
+// FromSource builds a recorder for the sinks configured in source, labelled
+// with the driver and, where Concourse provides them, the build's team,
+// pipeline and job.
+func FromSource(source models.Source, client *http.Client) *Recorder {
+	driver := source.Driver
+	if driver == models.DriverUnspecified {
+		driver = models.DriverS3
+	}
+
+	labels := map[string]string{
+		"driver": string(driver),
+	}
+
+	for label, env := range map[string]string{
+		"team":     "BUILD_TEAM_NAME",
+		"pipeline": "BUILD_PIPELINE_NAME",
+		"job":      "BUILD_JOB_NAME",
+	} {
+		if value := os.Getenv(env); value != "" {
+			labels[label] = value
+		}
+	}
+
+	sinks := []Sink{}
+
+	if source.Metrics.Pushgateway.URL != "" {
+		sinks = append(sinks, NewPushgateway(source.Metrics.Pushgateway, client))
+	}
+
+	if source.Metrics.StatsD.Address != "" {
+		sinks = append(sinks, NewStatsD(source.Metrics.StatsD))
+	}
+
+	return NewRecorder(labels, sinks...)
+}
+
+func (recorder *Recorder) Count(name string, help string) {
+	recorder.add(Metric{Name: name, Help: help, Kind: Counter, Value: 1})
+}
+
+func (recorder *Recorder) Gauge(name string, help string, value float64) {
+	recorder.add(Metric{Name: name, Help: help, Kind: Gauge, Value: value})
+}
+
+// Since records the time elapsed since start.
+func (recorder *Recorder) Since(name string, help string, start time.Time) {
+	recorder.add(Metric{Name: name, Help: help, Kind: Timer, Value: time.Since(start).Seconds()})
+}
+
+func (recorder *Recorder) add(metric Metric) {
+	if len(recorder.sinks) == 0 {
+		return
+	}
+
+	recorder.metrics = append(recorder.metrics, metric)
+}
+
+// Push emits the recorded metrics to every sink, returning the failures of
+// all sinks that could not be reached.
+func (recorder *Recorder) Push() error {
+	failures := []string{}
+
+	for _, sink := range recorder.sinks {
+		err := sink.Emit(recorder.labels, recorder.metrics)
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("pushing metrics: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
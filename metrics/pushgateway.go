@@ -0,0 +1,247 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+const namespace = "semver"
+
+// Pushgateway pushes metrics to a Prometheus Pushgateway, grouped by the
+// recorder's labels. Every push replaces the group's previous values, so
+// counters are read back from the gateway and incremented before pushing.
+// Concurrent pushes to the same group may therefore lose an increment.
+type Pushgateway struct {
+	URL string
+	Job string
+
+	client *http.Client
+}
+
+func NewPushgateway(config models.PushgatewayOptions, client *http.Client) *Pushgateway {
+	job := config.Job
+	if job == "" {
+		job = "semver-resource"
+	}
+
+	return &Pushgateway{
+		URL:    strings.TrimSuffix(config.URL, "/"),
+		Job:    job,
+		client: client,
+	}
+}
+
+func (gateway *Pushgateway) Emit(labels map[string]string, metrics []Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	grouping := map[string]string{"job": gateway.Job}
+	for name, value := range labels {
+		// the job is the gateway's grouping key, so the build's is renamed
+		if name == "job" {
+			name = "concourse_job"
+		}
+
+		grouping[name] = value
+	}
+
+	var current map[string]float64
+	for _, metric := range metrics {
+		if metric.Kind == Counter {
+			var err error
+			current, err = gateway.currentValues(grouping)
+			if err != nil {
+				return err
+			}
+
+			break
+		}
+	}
+
+	body := &bytes.Buffer{}
+	for _, metric := range metrics {
+		name, kind := exposedName(metric)
+
+		value := metric.Value
+		if metric.Kind == Counter {
+			value += current[name]
+		}
+
+		fmt.Fprintf(body, "# HELP %s %s\n", name, metric.Help)
+		fmt.Fprintf(body, "# TYPE %s %s\n", name, kind)
+		fmt.Fprintf(body, "%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+	}
+
+	// POST only replaces the metrics being pushed, leaving e.g. the check
+	// duration alone when pushing a bump.
+	response, err := gateway.client.Post(gateway.groupURL(grouping), "text/plain; version=0.0.4", body)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("pushgateway responded with %s", response.Status)
+	}
+
+	return nil
+}
+
+func exposedName(metric Metric) (string, string) {
+	switch metric.Kind {
+	case Counter:
+		return namespace + "_" + metric.Name + "_total", "counter"
+	case Timer:
+		return namespace + "_" + metric.Name + "_seconds", "gauge"
+	default:
+		return namespace + "_" + metric.Name, "gauge"
+	}
+}
+
+func (gateway *Pushgateway) groupURL(grouping map[string]string) string {
+	names := []string{}
+	for name := range grouping {
+		if name != "job" {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	path := "/metrics/job/" + url.PathEscape(grouping["job"])
+	for _, name := range names {
+		path += "/" + name + "/" + url.PathEscape(grouping[name])
+	}
+
+	return gateway.URL + path
+}
+
+// currentValues scrapes the gateway for the samples belonging to the group.
+func (gateway *Pushgateway) currentValues(grouping map[string]string) (map[string]float64, error) {
+	response, err := gateway.client.Get(gateway.URL + "/metrics")
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("pushgateway responded with %s", response.Status)
+	}
+
+	return parseGroup(response.Body, grouping)
+}
+
+func parseGroup(exposition io.Reader, grouping map[string]string) (map[string]float64, error) {
+	values := map[string]float64{}
+
+	scanner := bufio.NewScanner(exposition)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, namespace+"_") {
+			continue
+		}
+
+		name, labels, value, ok := parseSample(line)
+		if !ok {
+			continue
+		}
+
+		matches := true
+		for label, expected := range grouping {
+			if labels[label] != expected {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			values[name] = value
+		}
+	}
+
+	return values, scanner.Err()
+}
+
+// parseSample parses a line of the text exposition format, e.g.
+// `semver_bumps_total{driver="git",job="semver-resource"} 3`.
+func parseSample(line string) (string, map[string]string, float64, bool) {
+	labels := map[string]string{}
+
+	end := strings.IndexAny(line, "{ ")
+	if end == -1 {
+		return "", nil, 0, false
+	}
+
+	name := line[:end]
+	rest := line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		rest = rest[1:]
+
+		for {
+			rest = strings.TrimLeft(rest, ", ")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+
+			eq := strings.Index(rest, "=\"")
+			if eq == -1 {
+				return "", nil, 0, false
+			}
+
+			label := rest[:eq]
+			rest = rest[eq+2:]
+
+			value := &bytes.Buffer{}
+			closed := false
+			for i := 0; i < len(rest); i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+					switch rest[i] {
+					case 'n':
+						value.WriteByte('\n')
+					default:
+						value.WriteByte(rest[i])
+					}
+				} else if rest[i] == '"' {
+					rest = rest[i+1:]
+					closed = true
+					break
+				} else {
+					value.WriteByte(rest[i])
+				}
+			}
+
+			if !closed {
+				return "", nil, 0, false
+			}
+
+			labels[label] = value.String()
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, false
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+
+	return name, labels, value, true
+}
@@ -0,0 +1,88 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/concourse/semver-resource/metrics"
+	"github.com/concourse/semver-resource/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pushgateway", func() {
+	var server *httptest.Server
+	var exposition string
+
+	var pushedPath string
+	var pushedBody string
+
+	var recorder *metrics.Recorder
+
+	BeforeEach(func() {
+		exposition = ""
+		pushedPath = ""
+		pushedBody = ""
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				w.Write([]byte(exposition))
+				return
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			pushedPath = r.URL.EscapedPath()
+			pushedBody = string(body)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+
+		gateway := metrics.NewPushgateway(models.PushgatewayOptions{URL: server.URL + "/"}, http.DefaultClient)
+		recorder = metrics.NewRecorder(map[string]string{"driver": "git", "pipeline": "main pipeline"}, gateway)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("pushes to the group identified by the labels", func() {
+		recorder.Gauge("bump_conflict_retries", "Retries.", 2)
+		Expect(recorder.Push()).To(Succeed())
+
+		Expect(pushedPath).To(Equal("/metrics/job/semver-resource/driver/git/pipeline/main%20pipeline"))
+		Expect(pushedBody).To(Equal(`# HELP semver_bump_conflict_retries Retries.
+# TYPE semver_bump_conflict_retries gauge
+semver_bump_conflict_retries 2
+`))
+	})
+
+	It("keeps the configured job, pushing the build's as concourse_job", func() {
+		gateway := metrics.NewPushgateway(models.PushgatewayOptions{URL: server.URL, Job: "versions"}, http.DefaultClient)
+		recorder = metrics.NewRecorder(map[string]string{"driver": "git", "job": "ship"}, gateway)
+
+		recorder.Gauge("bump_conflict_retries", "Retries.", 2)
+		Expect(recorder.Push()).To(Succeed())
+
+		Expect(pushedPath).To(Equal("/metrics/job/versions/concourse_job/ship/driver/git"))
+	})
+
+	It("increments counters already in the group", func() {
+		exposition = `# TYPE semver_bumps_total counter
+semver_bumps_total{driver="git",instance="",job="semver-resource",pipeline="other"} 10
+semver_bumps_total{driver="git",instance="",job="semver-resource",pipeline="main pipeline"} 4
+`
+
+		recorder.Count("bumps", "Bumps.")
+		Expect(recorder.Push()).To(Succeed())
+
+		Expect(pushedBody).To(ContainSubstring("# TYPE semver_bumps_total counter\nsemver_bumps_total 5\n"))
+	})
+
+	It("does not push when nothing was recorded", func() {
+		Expect(recorder.Push()).To(Succeed())
+		Expect(pushedPath).To(BeEmpty())
+	})
+})
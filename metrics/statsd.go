@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// StatsD sends metrics over UDP to a StatsD daemon. With DogStatsD enabled,
+// the recorder's labels and any configured tags are attached to every
+// metric; plain StatsD has no notion of tags, so they are dropped.
+type StatsD struct {
+	Address   string
+	Prefix    string
+	DogStatsD bool
+	Tags      []string
+}
+
+func NewStatsD(config models.StatsDOptions) *StatsD {
+	prefix := config.Prefix
+	if prefix == "" {
+		prefix = namespace
+	}
+
+	return &StatsD{
+		Address:   config.Address,
+		Prefix:    prefix,
+		DogStatsD: config.DogStatsD,
+		Tags:      config.Tags,
+	}
+}
+
+func (statsd *StatsD) Emit(labels map[string]string, metrics []Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	conn, err := net.Dial("udp", statsd.Address)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	for _, metric := range metrics {
+		_, err := conn.Write([]byte(statsd.line(labels, metric)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (statsd *StatsD) line(labels map[string]string, metric Metric) string {
+	var value string
+	var kind string
+
+	switch metric.Kind {
+	case Counter:
+		value, kind = strconv.FormatFloat(metric.Value, 'g', -1, 64), "c"
+	case Timer:
+		value, kind = strconv.FormatFloat(metric.Value*1000, 'f', 3, 64), "ms"
+	default:
+		value, kind = strconv.FormatFloat(metric.Value, 'g', -1, 64), "g"
+	}
+
+	line := fmt.Sprintf("%s.%s:%s|%s", statsd.Prefix, metric.Name, value, kind)
+
+	if statsd.DogStatsD {
+		tags := append([]string{}, statsd.Tags...)
+
+		names := []string{}
+		for name := range labels {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			tags = append(tags, name+":"+labels[name])
+		}
+
+		if len(tags) > 0 {
+			line += "|#" + strings.Join(sanitizeTags(tags), ",")
+		}
+	}
+
+	return line
+}
+
+// sanitizeTags replaces the characters DogStatsD uses as separators.
+func sanitizeTags(tags []string) []string {
+	sanitized := make([]string, len(tags))
+	for i, tag := range tags {
+		sanitized[i] = strings.NewReplacer(",", "_", "|", "_", "#", "_").Replace(tag)
+	}
+
+	return sanitized
+}
@@ -0,0 +1,71 @@
+package metrics_test
+
+import (
+	"net"
+	"strings"
+
+	"github.com/concourse/semver-resource/metrics"
+	"github.com/concourse/semver-resource/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StatsD", func() {
+	var conn net.PacketConn
+	var options models.StatsDOptions
+
+	BeforeEach(func() {
+		var err error
+		conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		options = models.StatsDOptions{Address: conn.LocalAddr().String()}
+	})
+
+	AfterEach(func() {
+		conn.Close()
+	})
+
+	received := func(count int) []string {
+		lines := []string{}
+		buf := make([]byte, 1024)
+
+		for i := 0; i < count; i++ {
+			n, _, err := conn.ReadFrom(buf)
+			Expect(err).NotTo(HaveOccurred())
+
+			lines = append(lines, string(buf[:n]))
+		}
+
+		return lines
+	}
+
+	record := func() {
+		recorder := metrics.NewRecorder(map[string]string{"driver": "s3", "pipeline": "a,b"}, metrics.NewStatsD(options))
+		recorder.Count("bumps", "Bumps.")
+		recorder.Gauge("bump_conflict_retries", "Retries.", 3)
+		Expect(recorder.Push()).To(Succeed())
+	}
+
+	It("sends plain StatsD lines", func() {
+		record()
+
+		Expect(received(2)).To(Equal([]string{
+			"semver.bumps:1|c",
+			"semver.bump_conflict_retries:3|g",
+		}))
+	})
+
+	It("tags DogStatsD lines with the labels", func() {
+		options.DogStatsD = true
+		options.Prefix = "ci.semver"
+		options.Tags = []string{"env:prod"}
+
+		record()
+
+		lines := received(2)
+		Expect(lines[0]).To(Equal("ci.semver.bumps:1|c|#env:prod,driver:s3,pipeline:a_b"))
+		Expect(strings.HasPrefix(lines[1], "ci.semver.bump_conflict_retries:3|g|#")).To(BeTrue())
+	})
+})
@@ -0,0 +1,31 @@
+package models
+
+import "os"
+
+// BuildMetadata identifies the Concourse build running the resource, as
+// provided through the environment of `in` and `out`.
+type BuildMetadata struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Job      string `json:"job,omitempty"`
+	Pipeline string `json:"pipeline,omitempty"`
+	Team     string `json:"team,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+func BuildMetadataFromEnv() BuildMetadata {
+	build := BuildMetadata{
+		ID:       os.Getenv("BUILD_ID"),
+		Name:     os.Getenv("BUILD_NAME"),
+		Job:      os.Getenv("BUILD_JOB_NAME"),
+		Pipeline: os.Getenv("BUILD_PIPELINE_NAME"),
+		Team:     os.Getenv("BUILD_TEAM_NAME"),
+	}
+
+	externalURL := os.Getenv("ATC_EXTERNAL_URL")
+	if externalURL != "" && build.ID != "" {
+		build.URL = externalURL + "/builds/" + build.ID
+	}
+
+	return build
+}
@@ -16,8 +16,8 @@ type InResponse struct {
 }
 
 type InParams struct {
-	Bump string `json:"bump"`
-	Pre  string `json:"pre"`
+	Bump string `json:"bump" schema:"enum=major|minor|patch|final" description:"Bump the provided version locally."`
+	Pre  string `json:"pre" description:"Bump to, or within, the named prerelease."`
 }
 
 type OutRequest struct {
@@ -32,10 +32,18 @@ type OutResponse struct {
 }
 
 type OutParams struct {
-	File string `json:"file"`
+	File string `json:"file" description:"Path to a file containing the version number to set."`
 
-	Bump string `json:"bump"`
-	Pre  string `json:"pre"`
+	Bump string `json:"bump" schema:"enum=major|minor|patch|final" description:"Bump the current version atomically."`
+	Pre  string `json:"pre" description:"Bump to, or within, the named prerelease."`
+
+	BumpFile string `json:"bump_file" description:"Path to a file containing the bump to apply, e.g. as written by the analyze task."`
+
+	Notes string `json:"notes" description:"Path to a file containing release notes for the changelog entry."`
+
+	Reserve bool   `json:"reserve" description:"Reserve the version bump and pre make without publishing it (git only)."`
+	Commit  string `json:"commit" description:"Path to a file containing a reserved version to publish (git only)."`
+	Abandon string `json:"abandon" description:"Path to a file containing a reserved version to release (git only)."`
 }
 
 type CheckRequest struct {
@@ -46,35 +54,67 @@ type CheckRequest struct {
 type CheckResponse []Version
 
 type Source struct {
-	Driver Driver `json:"driver"`
+	Driver Driver `json:"driver" schema:"enum=s3|git|swift,default=s3" description:"Where the version is stored."`
+
+	InitialVersion string `json:"initial_version" schema:"default=0.0.0" description:"Version to use when none is present in the store."`
+
+	Bucket          string `json:"bucket" schema:"required,driver=s3" description:"Name of the bucket."`
+	Key             string `json:"key" schema:"required,driver=s3" description:"Key of the object tracking the version."`
+	AccessKeyID     string `json:"access_key_id" schema:"driver=s3,secret" description:"AWS access key."`
+	SecretAccessKey string `json:"secret_access_key" schema:"driver=s3,secret" description:"AWS secret key."`
+	SessionToken    string `json:"session_token" schema:"driver=s3,secret" description:"AWS session token, for temporary credentials."`
+	RegionName      string `json:"region_name" schema:"driver=s3,default=us-east-1" description:"Region the bucket is in."`
+	Endpoint        string `json:"endpoint" schema:"driver=s3" description:"Custom endpoint of an S3 compatible provider."`
+	DisableSSL      bool   `json:"disable_ssl" schema:"driver=s3,default=false" description:"Disable SSL for the endpoint."`
+
+	URI        string `json:"uri" schema:"required,driver=git" description:"Repository URL."`
+	Branch     string `json:"branch" schema:"required,driver=git" description:"Branch the file lives on."`
+	PrivateKey string `json:"private_key" schema:"driver=git,secret" description:"SSH private key for pulling and pushing."`
+	Username   string `json:"username" schema:"driver=git" description:"Username for HTTP(S) auth."`
+	Password   string `json:"password" schema:"driver=git,secret" description:"Password for HTTP(S) auth."`
+	File       string `json:"file" schema:"required,driver=git" description:"Name of the file in the repository."`
+	GitUser    string `json:"git_user" schema:"driver=git" description:"Git identity to commit as, e.g. \"Jane Doe <jd@example.com>\"."`
+
+	PrivateKeySecretID     string `json:"private_key_secret_id" schema:"driver=git" description:"Name or ARN of an AWS Secrets Manager secret holding the private key."`
+	PrivateKeySecretRegion string `json:"private_key_secret_region" schema:"driver=git" description:"Region of the private key's secret."`
+
+	OpenStack OpenStackOptions `json:"openstack" schema:"required,driver=swift" description:"OpenStack object storage configuration."`
+
+	CredentialsFrom CredentialsSource `json:"credentials_from" schema:"enum=vault" description:"Where to fetch the driver's credentials from at runtime."`
+	Vault           VaultOptions      `json:"vault" description:"Vault secret to read credentials from."`
+
+	Namespace          string   `json:"namespace" description:"Namespace, e.g. payments/api, the version and everything stored next to it live under in a shared store."`
+	WritableNamespaces []string `json:"writable_namespaces" description:"Patterns, e.g. payments/*, of the namespaces this source may change."`
+
+	AuditLog string `json:"audit_log" description:"File, key or item, next to the version, to append a record of every change to."`
+
+	MinBumpInterval  string `json:"min_bump_interval" description:"Minimum time between changes, e.g. 10m."`
+	CooldownBehavior string `json:"cooldown_behavior" schema:"enum=fail|return_existing,default=fail" description:"What a put within min_bump_interval of the last change does."`
 
-	InitialVersion string `json:"initial_version"`
+	Freeze FreezeOptions `json:"freeze" description:"Windows during which changes are rejected."`
 
-	Bucket          string `json:"bucket"`
-	Key             string `json:"key"`
-	AccessKeyID     string `json:"access_key_id"`
-	SecretAccessKey string `json:"secret_access_key"`
-	RegionName      string `json:"region_name"`
-	Endpoint        string `json:"endpoint"`
-	DisableSSL      bool   `json:"disable_ssl"`
+	Policy PolicyOptions `json:"policy" description:"Open Policy Agent policy every change must be allowed by."`
 
-	URI        string `json:"uri"`
-	Branch     string `json:"branch"`
-	PrivateKey string `json:"private_key"`
-	Username   string `json:"username"`
-	Password   string `json:"password"`
-	File       string `json:"file"`
-	GitUser    string `json:"git_user"`
+	Signing SigningOptions `json:"signing" description:"How to sign every change, so that versions can be verified with cosign."`
 
-	OpenStack OpenStackOptions `json:"openstack"`
+	Provenance ProvenanceOptions `json:"provenance" description:"Whether to emit, and store, a SLSA provenance attestation for every put."`
+
+	Changelog ChangelogOptions `json:"changelog" schema:"driver=git" description:"Changelog to prepend an entry to with every change."`
+
+	Metrics MetricsOptions `json:"metrics" description:"Where to emit metrics about each operation."`
+	Tracing TracingOptions `json:"tracing" description:"OpenTelemetry collector to export spans to."`
+
+	Slack SlackOptions `json:"slack" description:"Slack incoming webhook to post a message to after each bump."`
+
+	Webhook WebhookOptions `json:"webhook" description:"HTTP endpoint to POST to after each bump."`
 }
 
 // OpenStackOptions contains properties for authenticating and accessing
 // the object storage system.
 type OpenStackOptions struct {
-	Container string `json:"container"`
-	ItemName  string `json:"item_name"`
-	Region    string `json:"region"`
+	Container string `json:"container" schema:"required" description:"Name of the container."`
+	ItemName  string `json:"item_name" schema:"required" description:"Name of the object tracking the version."`
+	Region    string `json:"region" schema:"required" description:"Region the container is in."`
 
 	// Properties below are for authentication. Its a copy of
 	// the properties required by gophercloud. Review documentation
@@ -82,14 +122,108 @@ type OpenStackOptions struct {
 	IdentityEndpoint string `json:"identity_endpoint"`
 	Username         string `json:"username"`
 	UserID           string `json:"user_id"`
-	Password         string `json:"password"`
-	APIKey           string `json:"api_key"`
+	Password         string `json:"password" schema:"secret"`
+	APIKey           string `json:"api_key" schema:"secret"`
 	DomainID         string `json:"domain_id"`
 	DomainName       string `json:"domain_name"`
 	TenantID         string `json:"tenant_id"`
 	TenantName       string `json:"tenant_name"`
 	AllowReauth      bool   `json:"allow_reauth"`
-	TokenID          string `json:"token_id"`
+	TokenID          string `json:"token_id" schema:"secret"`
+}
+
+// MetricsOptions configures the sinks metrics are emitted to after each
+// operation.
+type MetricsOptions struct {
+	Pushgateway PushgatewayOptions `json:"pushgateway" description:"Prometheus Pushgateway to push metrics to."`
+	StatsD      StatsDOptions      `json:"statsd" description:"StatsD or DogStatsD daemon to send metrics to."`
+}
+
+type PushgatewayOptions struct {
+	URL string `json:"url" description:"Base URL of the Pushgateway."`
+	Job string `json:"job" schema:"default=semver-resource" description:"Job label to group the metrics under."`
+}
+
+type StatsDOptions struct {
+	Address   string   `json:"address" description:"host:port of the daemon, over UDP."`
+	Prefix    string   `json:"prefix" schema:"default=semver" description:"Prefix of every metric name."`
+	DogStatsD bool     `json:"dogstatsd" schema:"default=false" description:"Attach tags using the DogStatsD extension."`
+	Tags      []string `json:"tags" description:"Extra DogStatsD tags, e.g. env:prod."`
+}
+
+type TracingOptions struct {
+	Endpoint    string            `json:"endpoint" description:"Base URL of the collector's OTLP/HTTP receiver, e.g. http://collector:4318."`
+	Headers     map[string]string `json:"headers" schema:"secret" description:"Headers sent with every export, e.g. for authentication."`
+	ServiceName string            `json:"service_name" schema:"default=semver-resource" description:"service.name of the exported spans."`
+}
+
+type CredentialsSource string
+
+const (
+	CredentialsFromSource CredentialsSource = ""
+	CredentialsFromVault  CredentialsSource = "vault"
+)
+
+type VaultOptions struct {
+	URL       string `json:"url" description:"Address of the Vault server."`
+	Path      string `json:"path" description:"Path of the secret to read, e.g. secret/data/ci/semver or aws/creds/semver."`
+	Token     string `json:"token" schema:"secret" description:"Token to authenticate with."`
+	RoleID    string `json:"role_id" description:"AppRole role ID to log in with instead of a token."`
+	SecretID  string `json:"secret_id" schema:"secret" description:"AppRole secret ID to log in with instead of a token."`
+	AuthMount string `json:"auth_mount" schema:"default=approle" description:"Path the AppRole auth method is mounted at."`
+	Namespace string `json:"namespace" description:"Vault Enterprise namespace."`
+}
+
+type FreezeOptions struct {
+	Timezone string         `json:"timezone" schema:"default=UTC" description:"IANA timezone the windows are in, e.g. Europe/London."`
+	Windows  []FreezeWindow `json:"windows" description:"Date ranges or cron expressions during which changes are rejected."`
+}
+
+type FreezeWindow struct {
+	Cron   string `json:"cron" description:"Cron expression matching every frozen minute, e.g. \"* 16-23 * * 5\"."`
+	From   string `json:"from" description:"Start of a frozen date range, e.g. 2026-12-21 or 2026-12-21T17:00."`
+	To     string `json:"to" description:"End of a frozen date range. A date alone includes that day."`
+	Reason string `json:"reason" description:"Explanation shown when a change is rejected."`
+}
+
+type PolicyOptions struct {
+	URL      string            `json:"url" description:"Base URL of an OPA server to query."`
+	Headers  map[string]string `json:"headers" schema:"secret" description:"Headers to send to the OPA server, e.g. for authentication."`
+	Decision string            `json:"decision" schema:"default=semver/allow" description:"Path of the decision to query."`
+}
+
+type SigningOptions struct {
+	Key           string `json:"key" schema:"secret" description:"PEM-encoded ECDSA private key to sign with."`
+	Keyless       bool   `json:"keyless" description:"Sign with a certificate from Fulcio instead of a key."`
+	IdentityToken string `json:"identity_token" schema:"secret" description:"OIDC token identifying the signer for keyless signing."`
+	FulcioURL     string `json:"fulcio_url" schema:"default=https://fulcio.sigstore.dev" description:"Fulcio instance issuing keyless certificates."`
+	RekorURL      string `json:"rekor_url" schema:"default=https://rekor.sigstore.dev" description:"Rekor instance recording keyless signatures."`
+	Name          string `json:"name" description:"Name to store the signature under, next to the version."`
+}
+
+type ProvenanceOptions struct {
+	Emit      bool   `json:"emit" description:"Print the attestation and its digest for every put."`
+	Store     bool   `json:"store" description:"Also store the attestation next to the version."`
+	Name      string `json:"name" description:"Name to store the attestation under."`
+	BuilderID string `json:"builder_id" description:"URI identifying the builder, by default the Concourse instance."`
+}
+
+type ChangelogOptions struct {
+	File     string `json:"file" description:"Name of the changelog in the repository."`
+	Template string `json:"template" description:"Go template for each entry."`
+}
+
+type SlackOptions struct {
+	WebhookURL string `json:"webhook_url" schema:"secret" description:"URL of the incoming webhook."`
+	Channel    string `json:"channel" description:"Channel to post to instead of the webhook's default."`
+	Username   string `json:"username" description:"Name to post as instead of the webhook's default."`
+	Name       string `json:"name" schema:"default=version" description:"What the version is of, e.g. the application's name."`
+}
+
+type WebhookOptions struct {
+	URL     string            `json:"url" schema:"secret" description:"URL to POST to."`
+	Headers map[string]string `json:"headers" schema:"secret" description:"Headers to send with the request, e.g. for authentication."`
+	Body    string            `json:"body" description:"Go template for the JSON request body, executed against the change."`
 }
 
 type Metadata []MetadataField
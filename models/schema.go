@@ -0,0 +1,292 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The `schema` struct tag declares how a configuration field is validated
+// and described. It is a comma-separated list of options:
+//
+//	required     the field must be set
+//	driver=NAME  the field only applies when using the named driver
+//	default=VAL  the value used when the field is not set
+//	enum=A|B     the field, when set, must be one of the listed values
+//	secret       the field holds a credential
+//
+// A human-readable summary of the field goes in the `description` tag.
+
+// Schema is the subset of JSON Schema (draft-07) needed to describe the
+// resource's configuration.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	If                   *Schema            `json:"if,omitempty"`
+	Then                 *Schema            `json:"then,omitempty"`
+}
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError []string
+
+func (err ValidationError) Error() string {
+	return strings.Join(err, "; ")
+}
+
+// FieldSpec is the parsed form of a field's `json`, `schema` and
+// `description` tags.
+type FieldSpec struct {
+	Name        string
+	Required    bool
+	Driver      Driver
+	Default     string
+	Enum        []string
+	Secret      bool
+	Description string
+}
+
+// SpecOf parses the tags of a struct field. It returns false for fields that
+// are not part of the JSON configuration.
+func SpecOf(field reflect.StructField) (FieldSpec, bool) {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return FieldSpec{}, false
+	}
+
+	spec := FieldSpec{
+		Name:        name,
+		Description: field.Tag.Get("description"),
+	}
+
+	for _, option := range strings.Split(field.Tag.Get("schema"), ",") {
+		switch {
+		case option == "required":
+			spec.Required = true
+		case option == "secret":
+			spec.Secret = true
+		case strings.HasPrefix(option, "driver="):
+			spec.Driver = Driver(strings.TrimPrefix(option, "driver="))
+		case strings.HasPrefix(option, "default="):
+			spec.Default = strings.TrimPrefix(option, "default=")
+		case strings.HasPrefix(option, "enum="):
+			spec.Enum = strings.Split(strings.TrimPrefix(option, "enum="), "|")
+		}
+	}
+
+	return spec, true
+}
+
+// SchemaOf describes the configuration struct v as a JSON Schema. Fields
+// required only by a particular driver become conditional requirements on
+// the `driver` property.
+func SchemaOf(v interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func structSchema(t reflect.Type) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+	}
+
+	requiredByDriver := map[Driver][]string{}
+	defaultDriver := ""
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		spec, ok := SpecOf(field)
+		if !ok {
+			continue
+		}
+
+		property := schemaOf(field.Type)
+		property.Description = spec.Description
+		property.Enum = spec.Enum
+		property.WriteOnly = spec.Secret
+
+		if spec.Default != "" {
+			property.Default = defaultValue(field.Type.Kind(), spec.Default)
+		}
+
+		if field.Type == reflect.TypeOf(DriverUnspecified) {
+			defaultDriver = spec.Default
+		}
+
+		if spec.Required {
+			if spec.Driver == DriverUnspecified {
+				schema.Required = append(schema.Required, spec.Name)
+			} else {
+				requiredByDriver[spec.Driver] = append(requiredByDriver[spec.Driver], spec.Name)
+			}
+		}
+
+		schema.Properties[spec.Name] = property
+	}
+
+	drivers := []string{}
+	for driver := range requiredByDriver {
+		drivers = append(drivers, string(driver))
+	}
+
+	sort.Strings(drivers)
+
+	for _, driver := range drivers {
+		matching := []string{driver}
+		if driver == defaultDriver {
+			matching = append(matching, string(DriverUnspecified))
+		}
+
+		schema.AllOf = append(schema.AllOf, &Schema{
+			If: &Schema{
+				Properties: map[string]*Schema{
+					"driver": {Enum: matching},
+				},
+			},
+			Then: &Schema{
+				Required: requiredByDriver[Driver(driver)],
+			},
+		})
+	}
+
+	return schema
+}
+
+func defaultValue(kind reflect.Kind, value string) interface{} {
+	switch kind {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	}
+
+	return value
+}
+
+// Validate checks the configuration struct v against its `schema` tags. If v
+// has a Driver field, fields belonging to other drivers are ignored.
+func Validate(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+
+	driver, scoped := activeDriver(value)
+
+	problems := ValidationError{}
+	validateStruct(value, driver, scoped, "", &problems)
+
+	if len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
+
+func activeDriver(value reflect.Value) (Driver, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Type != reflect.TypeOf(DriverUnspecified) {
+			continue
+		}
+
+		driver := Driver(value.Field(i).String())
+		if driver == DriverUnspecified {
+			spec, _ := SpecOf(field)
+			driver = Driver(spec.Default)
+		}
+
+		return driver, true
+	}
+
+	return DriverUnspecified, false
+}
+
+func validateStruct(value reflect.Value, driver Driver, scoped bool, prefix string, problems *ValidationError) {
+	for i := 0; i < value.NumField(); i++ {
+		spec, ok := SpecOf(value.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		if scoped && spec.Driver != DriverUnspecified && spec.Driver != driver {
+			continue
+		}
+
+		name := prefix + spec.Name
+		field := value.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			validateStruct(field, driver, scoped, name+"/", problems)
+			continue
+		}
+
+		if isEmpty(field) {
+			if spec.Required {
+				*problems = append(*problems, fmt.Sprintf("%s is empty but must be specified", name))
+			}
+
+			continue
+		}
+
+		if len(spec.Enum) > 0 && field.Kind() == reflect.String && !contains(spec.Enum, field.String()) {
+			*problems = append(*problems, fmt.Sprintf("%s must be one of %s, got '%s'", name, strings.Join(spec.Enum, ", "), field.String()))
+		}
+	}
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	default:
+		return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
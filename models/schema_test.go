@@ -0,0 +1,78 @@
+package models_test
+
+import (
+	"github.com/concourse/semver-resource/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema", func() {
+	Describe("Validate", func() {
+		It("requires the fields of the default driver", func() {
+			err := models.Validate(models.Source{})
+			Expect(err).To(MatchError("bucket is empty but must be specified; key is empty but must be specified"))
+		})
+
+		It("ignores fields of other drivers", func() {
+			err := models.Validate(models.Source{
+				Driver: models.DriverGit,
+				URI:    "git@example.com:some/repo.git",
+				Branch: "version",
+				File:   "version",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("validates nested options", func() {
+			err := models.Validate(models.Source{
+				Driver: models.DriverSwift,
+				OpenStack: models.OpenStackOptions{
+					Container: "c",
+					Region:    "region",
+				},
+			})
+			Expect(err).To(MatchError("openstack/item_name is empty but must be specified"))
+		})
+
+		It("rejects values outside of an enum", func() {
+			err := models.Validate(models.OutParams{Bump: "mega"})
+			Expect(err).To(MatchError("bump must be one of major, minor, patch, final, got 'mega'"))
+		})
+
+		It("accepts empty optional values", func() {
+			Expect(models.Validate(models.OutParams{})).To(Succeed())
+		})
+	})
+
+	Describe("SchemaOf", func() {
+		var schema *models.Schema
+
+		BeforeEach(func() {
+			schema = models.SchemaOf(models.Source{})
+		})
+
+		It("describes each property", func() {
+			Expect(schema.Type).To(Equal("object"))
+			Expect(schema.Properties["driver"].Enum).To(Equal([]string{"s3", "git", "swift"}))
+			Expect(schema.Properties["driver"].Default).To(Equal("s3"))
+			Expect(schema.Properties["disable_ssl"].Type).To(Equal("boolean"))
+			Expect(schema.Properties["disable_ssl"].Default).To(Equal(false))
+			Expect(schema.Properties["private_key"].WriteOnly).To(BeTrue())
+			Expect(schema.Properties["openstack"].Required).To(Equal([]string{"container", "item_name", "region"}))
+		})
+
+		It("makes driver fields conditionally required", func() {
+			Expect(schema.Required).To(BeEmpty())
+			Expect(schema.AllOf).To(HaveLen(3))
+
+			git := schema.AllOf[0]
+			Expect(git.If.Properties["driver"].Enum).To(Equal([]string{"git"}))
+			Expect(git.Then.Required).To(Equal([]string{"uri", "branch", "file"}))
+
+			s3 := schema.AllOf[1]
+			Expect(s3.If.Properties["driver"].Enum).To(Equal([]string{"s3", ""}))
+			Expect(s3.Then.Required).To(Equal([]string{"bucket", "key"}))
+		})
+	})
+})
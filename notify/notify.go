@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// Event describes a version successfully written by `out`.
+type Event struct {
+	// From is the version that was replaced, or empty if it is not known.
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+
+	// Bump describes how To was derived, or is "set" for an explicit version.
+	Bump string `json:"bump"`
+
+	Build models.BuildMetadata `json:"build"`
+}
+
+type Notifier interface {
+	Notify(Event) error
+}
+
+// FromSource returns a notifier for every destination configured in source,
+// or a models.ValidationError if one is misconfigured.
+func FromSource(source models.Source, client *http.Client) ([]Notifier, error) {
+	notifiers := []Notifier{}
+
+	if source.Slack.WebhookURL != "" {
+		notifiers = append(notifiers, NewSlack(source.Slack, client))
+	}
+
+	if source.Webhook.URL != "" {
+		webhook, err := NewWebhook(source.Webhook, client)
+		if err != nil {
+			return nil, models.ValidationError{err.Error()}
+		}
+
+		notifiers = append(notifiers, webhook)
+	}
+
+	return notifiers, nil
+}
+
+// NotifyAll sends event to every notifier, returning the failures of all
+// that could not be notified.
+func NotifyAll(notifiers []Notifier, event Event) error {
+	failures := []string{}
+
+	for _, notifier := range notifiers {
+		err := notifier.Notify(event)
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("notifying: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
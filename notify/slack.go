@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// Slack posts a message about each event to an incoming webhook.
+type Slack struct {
+	WebhookURL string
+	Channel    string
+	Username   string
+	Name       string
+
+	client *http.Client
+}
+
+func NewSlack(options models.SlackOptions, client *http.Client) *Slack {
+	name := options.Name
+	if name == "" {
+		name = "version"
+	}
+
+	return &Slack{
+		WebhookURL: options.WebhookURL,
+		Channel:    options.Channel,
+		Username:   options.Username,
+		Name:       name,
+		client:     client,
+	}
+}
+
+type slackMessage struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+func (slack *Slack) Notify(event Event) error {
+	payload, err := json.Marshal(slackMessage{
+		Text:     slack.Message(event),
+		Channel:  slack.Channel,
+		Username: slack.Username,
+	})
+	if err != nil {
+		return err
+	}
+
+	response, err := slack.client.Post(slack.WebhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("slack responded with %s", response.Status)
+	}
+
+	return nil
+}
+
+// Message renders event for Slack, e.g. "app bumped 1.4.2 → 1.5.0 by
+// pipeline main (job ship, build #42)", linking to the build when possible.
+func (slack *Slack) Message(event Event) string {
+	var text string
+	if event.From != "" && event.From != event.To {
+		text = fmt.Sprintf("%s bumped %s → %s", slack.Name, event.From, event.To)
+	} else {
+		text = fmt.Sprintf("%s set to %s", slack.Name, event.To)
+	}
+
+	build := event.Build
+	if build.Pipeline == "" {
+		return text
+	}
+
+	text += " by pipeline " + build.Pipeline
+
+	details := ""
+	if build.Job != "" {
+		details = "job " + build.Job
+	}
+
+	if build.Name != "" {
+		buildName := "build #" + build.Name
+		if build.URL != "" {
+			buildName = fmt.Sprintf("<%s|%s>", build.URL, buildName)
+		}
+
+		if details != "" {
+			details += ", "
+		}
+
+		details += buildName
+	}
+
+	if details != "" {
+		text += " (" + details + ")"
+	}
+
+	return text
+}
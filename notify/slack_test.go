@@ -0,0 +1,83 @@
+package notify_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/notify"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Slack", func() {
+	var slack *notify.Slack
+	var event notify.Event
+
+	BeforeEach(func() {
+		slack = notify.NewSlack(models.SlackOptions{Name: "app"}, http.DefaultClient)
+
+		event = notify.Event{
+			From: "1.4.2",
+			To:   "1.5.0",
+			Bump: "minor",
+			Build: models.BuildMetadata{
+				Name:     "42",
+				Job:      "ship",
+				Pipeline: "main",
+				URL:      "https://ci.example.com/builds/1234",
+			},
+		}
+	})
+
+	Describe("Message", func() {
+		It("describes the bump and links to the build", func() {
+			Expect(slack.Message(event)).To(Equal("app bumped 1.4.2 → 1.5.0 by pipeline main (job ship, <https://ci.example.com/builds/1234|build #42>)"))
+		})
+
+		It("describes versions that were set", func() {
+			event.From = ""
+			event.Build = models.BuildMetadata{}
+
+			Expect(slack.Message(event)).To(Equal("app set to 1.5.0"))
+		})
+	})
+
+	Describe("Notify", func() {
+		var server *httptest.Server
+		var posted map[string]string
+		var status int
+
+		BeforeEach(func() {
+			posted = nil
+			status = http.StatusOK
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(json.NewDecoder(r.Body).Decode(&posted)).To(Succeed())
+				w.WriteHeader(status)
+			}))
+
+			slack = notify.NewSlack(models.SlackOptions{
+				WebhookURL: server.URL,
+				Channel:    "#releases",
+			}, http.DefaultClient)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("posts the message to the webhook", func() {
+			Expect(slack.Notify(event)).To(Succeed())
+			Expect(posted).To(HaveKeyWithValue("channel", "#releases"))
+			Expect(posted["text"]).To(HavePrefix("version bumped 1.4.2 → 1.5.0"))
+		})
+
+		It("fails when the webhook rejects the message", func() {
+			status = http.StatusNotFound
+			Expect(slack.Notify(event)).To(MatchError("slack responded with 404 Not Found"))
+		})
+	})
+})
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// Webhook POSTs a JSON document describing each event to an arbitrary URL.
+type Webhook struct {
+	URL     string
+	Headers map[string]string
+
+	// body is executed against the Event. If nil, the Event itself is sent.
+	body *template.Template
+
+	client *http.Client
+}
+
+// NewWebhook returns a webhook for options, failing if its body is not a
+// valid text/template, so that it does not go unnoticed until the first
+// event.
+func NewWebhook(options models.WebhookOptions, client *http.Client) (*Webhook, error) {
+	webhook := &Webhook{
+		URL:     options.URL,
+		Headers: options.Headers,
+		client:  client,
+	}
+
+	if options.Body != "" {
+		body, err := template.New("body").Funcs(templateFuncs).Parse(options.Body)
+		if err != nil {
+			return nil, fmt.Errorf("parsing webhook body: %s", err)
+		}
+
+		webhook.body = body
+	}
+
+	return webhook, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+}
+
+func (webhook *Webhook) Notify(event Event) error {
+	payload, err := webhook.Payload(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	for name, value := range webhook.Headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := webhook.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook responded with %s", response.Status)
+	}
+
+	return nil
+}
+
+// Payload renders the request body for event, checking that it is valid
+// JSON.
+func (webhook *Webhook) Payload(event Event) ([]byte, error) {
+	if webhook.body == nil {
+		return json.Marshal(event)
+	}
+
+	buf := new(bytes.Buffer)
+
+	err := webhook.body.Execute(buf, event)
+	if err != nil {
+		return nil, fmt.Errorf("rendering webhook body: %s", err)
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook body is not valid JSON: %s", buf.String())
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,115 @@
+package notify_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/notify"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook", func() {
+	var webhook *notify.Webhook
+	var event notify.Event
+
+	newWebhook := func(options models.WebhookOptions) *notify.Webhook {
+		webhook, err := notify.NewWebhook(options, http.DefaultClient)
+		Expect(err).NotTo(HaveOccurred())
+		return webhook
+	}
+
+	BeforeEach(func() {
+		webhook = newWebhook(models.WebhookOptions{})
+
+		event = notify.Event{
+			From: "1.4.2",
+			To:   "1.5.0",
+			Bump: "minor",
+			Build: models.BuildMetadata{
+				Pipeline: "main",
+				Job:      "ship",
+			},
+		}
+	})
+
+	Describe("Payload", func() {
+		It("sends the event by default", func() {
+			payload, err := webhook.Payload(event)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(payload).To(MatchJSON(`{
+				"from": "1.4.2",
+				"to": "1.5.0",
+				"bump": "minor",
+				"build": {"job": "ship", "pipeline": "main"}
+			}`))
+		})
+
+		It("renders the body template against the event", func() {
+			webhook = newWebhook(models.WebhookOptions{
+				Body: `{"service": "app", "version": {{json .To}}, "pipeline": {{json .Build.Pipeline}}}`,
+			})
+
+			payload, err := webhook.Payload(event)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(payload).To(MatchJSON(`{"service": "app", "version": "1.5.0", "pipeline": "main"}`))
+		})
+
+		It("fails if the rendered body is not JSON", func() {
+			webhook = newWebhook(models.WebhookOptions{Body: `{"version": {{.To}}}`})
+
+			_, err := webhook.Payload(event)
+			Expect(err).To(MatchError(`webhook body is not valid JSON: {"version": 1.5.0}`))
+		})
+
+	})
+
+	It("fails to be created if the template is malformed", func() {
+		_, err := notify.NewWebhook(models.WebhookOptions{Body: `{{.To`}, http.DefaultClient)
+		Expect(err).To(MatchError(HavePrefix("parsing webhook body: ")))
+	})
+
+	It("is rejected as invalid configuration if the template is malformed", func() {
+		_, err := notify.FromSource(models.Source{
+			Webhook: models.WebhookOptions{URL: "https://example.com", Body: `{{.To`},
+		}, http.DefaultClient)
+		Expect(err).To(BeAssignableToTypeOf(models.ValidationError{}))
+	})
+
+	Describe("Notify", func() {
+		var server *httptest.Server
+		var request *http.Request
+		var body []byte
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var err error
+				body, err = ioutil.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				request = r
+			}))
+
+			webhook = newWebhook(models.WebhookOptions{
+				URL:     server.URL + "/releases",
+				Headers: map[string]string{"Authorization": "Bearer token"},
+				Body:    `{"version": {{json .To}}}`,
+			})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("posts the payload with the configured headers", func() {
+			Expect(webhook.Notify(event)).To(Succeed())
+			Expect(request.Method).To(Equal("POST"))
+			Expect(request.URL.Path).To(Equal("/releases"))
+			Expect(request.Header.Get("Authorization")).To(Equal("Bearer token"))
+			Expect(request.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(body).To(MatchJSON(`{"version": "1.5.0"}`))
+		})
+	})
+})
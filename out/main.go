@@ -1,24 +1,49 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/blang/semver"
 
 	"github.com/concourse/semver-resource/driver"
+	"github.com/concourse/semver-resource/metrics"
 	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/notify"
+	"github.com/concourse/semver-resource/policy"
+	"github.com/concourse/semver-resource/provenance"
+	"github.com/concourse/semver-resource/tracing"
 	"github.com/concourse/semver-resource/version"
 )
 
+// attacher is implemented by drivers that write attachments, like a changelog,
+// together with the version.
+type attacher interface {
+	Attach(driver.Attachment)
+}
+
+// guarded is implemented by drivers that check every change with guards just
+// before writing it.
+type guarded interface {
+	Guard(driver.Guard)
+}
+
 func main() {
 	if len(os.Args) < 2 {
 		println("usage: " + os.Args[0] + " <source>")
 		os.Exit(1)
 	}
 
+	startedOn := time.Now()
+
 	sources := os.Args[1]
 
 	var request models.OutRequest
@@ -27,60 +52,483 @@ func main() {
 		fatal("reading request", err)
 	}
 
+	requestSource = &request.Source
+
+	request.Source, err = driver.Namespaced(request.Source)
+	if err != nil {
+		fatal("resolving namespace", err)
+	}
+
+	err = models.Validate(request.Params)
+	if err != nil {
+		fatal("validating params", err)
+	}
+
+	modes := 0
+	for _, set := range []bool{request.Params.File != "", request.Params.Reserve, request.Params.Commit != "", request.Params.Abandon != ""} {
+		if set {
+			modes++
+		}
+	}
+
+	if modes > 1 {
+		fatal("validating params", models.ValidationError{"only one of file, reserve, commit and abandon may be given"})
+	}
+
+	recorder := metrics.FromSource(request.Source, driver.HTTPClient)
+	notifiers, err := notify.FromSource(request.Source, driver.HTTPClient)
+	if err != nil {
+		fatal("configuring notifications", err)
+	}
+
+	guards, err := policy.FromSource(request.Source, driver.HTTPClient)
+	if err != nil {
+		fatal("configuring policy", err)
+	}
+
+	tracing.Configure(request.Source.Tracing, driver.HTTPClient)
+
+	span := tracing.Start("out")
+
+	attachments := []driver.Attachment{}
+
+	if request.Source.Changelog.File != "" {
+		if request.Source.Driver != models.DriverGit {
+			fatal("configuring changelog", models.ValidationError{"changelogs are only supported by the git driver"})
+		}
+
+		var notes []byte
+		if request.Params.Notes != "" {
+			notes, err = ioutil.ReadFile(filepath.Join(sources, request.Params.Notes))
+			if err != nil {
+				fatal("reading notes file", err)
+			}
+		}
+
+		attachment, err := driver.Changelog(request.Source.Changelog.File, request.Source.Changelog.Template, string(notes))
+		if err != nil {
+			fatal("configuring changelog", err)
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	// the stored attestation is generated as the version is written, so that
+	// it describes the change actually made
+	var statement *provenance.Statement
+	if request.Source.Provenance.Store {
+		attachments = append(attachments, driver.Attachment{
+			Name: provenance.StoredName(request.Source.Provenance, driver.VersionName(request.Source)),
+			Render: func(previous []byte, change driver.Change) ([]byte, error) {
+				from := ""
+				if change.From != nil {
+					from = change.From.String()
+				}
+
+				generated := generateProvenance(request, from, change.To.String(), change.Bump, startedOn)
+				statement = &generated
+
+				return generated.Marshal()
+			},
+		})
+	}
+
 	driver, err := driver.FromSource(request.Source)
 	if err != nil {
 		fatal("constructing driver", err)
 	}
 
+	if len(attachments) > 0 {
+		attacher, ok := driver.(attacher)
+		if !ok {
+			fatal("configuring driver", models.ValidationError{"driver cannot store attachments"})
+		}
+
+		for _, attachment := range attachments {
+			attacher.Attach(attachment)
+		}
+	}
+
+	if len(guards) > 0 {
+		err = guard(driver, guards, request.Source)
+		if err != nil {
+			fatal("configuring driver", err)
+		}
+	}
+
+	bumpStr := request.Params.Bump
+	if request.Params.BumpFile != "" {
+		bumpStr, err = readBumpFile(filepath.Join(sources, request.Params.BumpFile))
+		if err != nil {
+			fatal("reading bump file", err)
+		}
+	}
+
+	start := time.Now()
+
+	event := notify.Event{
+		Bump:  "set",
+		Build: models.BuildMetadataFromEnv(),
+	}
+
 	var newVersion semver.Version
-	if request.Params.File != "" {
-		versionFile, err := os.Open(filepath.Join(sources, request.Params.File))
+	if request.Params.Reserve {
+		reserver, err := reserverOf(driver)
+		if err != nil {
+			fatal("reserving version", err)
+		}
+
+		if (bumpStr == "" || bumpStr == "none") && request.Params.Pre == "" {
+			fatal("reserving version", models.ValidationError{"reserve requires a bump or pre"})
+		}
+
+		bump := version.BumpFromParams(bumpStr, request.Params.Pre)
+
+		reserved, err := reserver.Reserve(bump)
 		if err != nil {
-			fatal("opening version file", err)
+			fatal("reserving version", err)
 		}
 
-		defer versionFile.Close()
+		finish(span, reserved)
+		return
+	} else if request.Params.Abandon != "" {
+		reserver, err := reserverOf(driver)
+		if err != nil {
+			fatal("abandoning version", err)
+		}
 
-		var versionStr string
-		_, err = fmt.Fscanf(versionFile, "%s", &versionStr)
+		reserved, err := readVersionFile(filepath.Join(sources, request.Params.Abandon))
 		if err != nil {
 			fatal("reading version file", err)
 		}
 
-		newVersion, err = semver.Parse(versionStr)
+		err = checkNamespace(guards, request.Source.Namespace)
+		if err != nil {
+			fatal("checking policy", err)
+		}
+
+		err = reserver.Abandon(reserved)
 		if err != nil {
-			fatal("parsing version", err)
+			fatal("abandoning version", err)
+		}
+
+		finish(span, reserved)
+		return
+	} else if request.Params.Commit != "" {
+		reserver, err := reserverOf(driver)
+		if err != nil {
+			fatal("committing version", err)
+		}
+
+		newVersion, err = readVersionFile(filepath.Join(sources, request.Params.Commit))
+		if err != nil {
+			fatal("reading version file", err)
+		}
+
+		// the reservation is released even if no version is written for the
+		// guards to check
+		err = checkNamespace(guards, request.Source.Namespace)
+		if err != nil {
+			fatal("checking policy", err)
+		}
+
+		// a commit on cooldown leaves the version reserved, to be committed
+		// again once the cooldown has passed
+		published, err := reserver.Commit(newVersion)
+		if current, ok := existingOnCooldown(request.Source, err); ok {
+			finish(span, current)
+			return
+		}
+
+		if err != nil {
+			fatal("committing version", err)
+		}
+
+		if !published {
+			fmt.Fprintf(os.Stderr, "released %s without publishing it, as a later version is current\n", newVersion)
+			finish(span, newVersion)
+			return
+		}
+	} else if request.Params.File != "" {
+		newVersion, err = readVersionFile(filepath.Join(sources, request.Params.File))
+		if err != nil {
+			fatal("reading version file", err)
 		}
 
 		err = driver.Set(newVersion)
+		if current, ok := existingOnCooldown(request.Source, err); ok {
+			finish(span, current)
+			return
+		}
+
 		if err != nil {
 			fatal("setting version", err)
 		}
-	} else if request.Params.Bump != "" || request.Params.Pre != "" {
-		bump := version.BumpFromParams(request.Params.Bump, request.Params.Pre)
+	} else if bumpStr == "none" && request.Params.Pre == "" {
+		// nothing to release; report the current version unchanged
+		versions, err := driver.Check(nil)
+		if err != nil {
+			fatal("checking version", err)
+		}
+
+		finish(span, versions[len(versions)-1])
+		return
+	} else if bumpStr != "" || request.Params.Pre != "" {
+		bump := &version.RecordingBump{
+			Bump: version.BumpFromParams(bumpStr, request.Params.Pre),
+		}
 
 		newVersion, err = driver.Bump(bump)
+		if current, ok := existingOnCooldown(request.Source, err); ok {
+			finish(span, current)
+			return
+		}
+
 		if err != nil {
 			fatal("bumping version", err)
 		}
+
+		event.Bump = bump.String()
+		event.From = bump.Applied.String()
 	} else {
 		println("no version bump specified")
 		os.Exit(1)
 	}
 
+	recorder.Count("bumps", "Number of versions written.")
+	recorder.Since("bump_duration", "Time taken to write the latest version.", start)
+
+	// drivers that compare-and-swap report how often they lost a race
+	if counter, ok := driver.(interface {
+		Conflicts() int
+	}); ok {
+		recorder.Gauge("bump_conflict_retries", "Writes of the latest version retried after losing a race.", float64(counter.Conflicts()))
+	}
+
+	err = recorder.Push()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	event.To = newVersion.String()
+
+	err = notify.NotifyAll(notifiers, event)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	metadata := models.Metadata{}
+
+	if request.Source.Provenance.Emit || request.Source.Provenance.Store {
+		if statement == nil {
+			generated := generateProvenance(request, event.From, event.To, event.Bump, startedOn)
+			statement = &generated
+		}
+
+		attestation, err := statement.Marshal()
+		if err != nil {
+			fatal("encoding provenance", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "provenance:\n%s\n", attestation)
+
+		digest := sha256.Sum256(attestation)
+		metadata = append(metadata, models.MetadataField{Name: "provenance_sha256", Value: hex.EncodeToString(digest[:])})
+	}
+
+	span.End(nil)
+
+	err = tracing.Flush()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	respond(newVersion, metadata...)
+}
+
+// finish reports an existing version without having written anything.
+func finish(span *tracing.Span, existing semver.Version) {
+	span.End(nil)
+
+	err := tracing.Flush()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	respond(existing)
+}
+
+func respond(newVersion semver.Version, metadata ...models.MetadataField) {
 	outVersion := models.Version{
 		Number: newVersion.String(),
 	}
 
 	json.NewEncoder(os.Stdout).Encode(models.OutResponse{
 		Version: outVersion,
-		Metadata: models.Metadata{
-			{"number", outVersion.Number},
-		},
+		Metadata: append(models.Metadata{
+			{Name: "number", Value: outVersion.Number},
+		}, metadata...),
 	})
 }
 
+func generateProvenance(request models.OutRequest, from string, to string, bump string, startedOn time.Time) provenance.Statement {
+	builderID := request.Source.Provenance.BuilderID
+	if builderID == "" {
+		builderID = os.Getenv("ATC_EXTERNAL_URL")
+	}
+
+	return provenance.Generate(provenance.Invocation{
+		Name:     driver.VersionName(request.Source),
+		Location: driver.Location(request.Source),
+		Driver:   request.Source.Driver,
+
+		From: from,
+		To:   to,
+		Bump: bump,
+
+		Params: request.Params,
+
+		BuilderID: builderID,
+		Build:     models.BuildMetadataFromEnv(),
+
+		StartedOn:  startedOn,
+		FinishedOn: time.Now(),
+	})
+}
+
+// guard has store check every change it writes with guards.
+func guard(store driver.Driver, guards []policy.Guard, source models.Source) error {
+	guarded, ok := store.(guarded)
+	if !ok {
+		return models.ValidationError{"driver cannot check changes with a policy"}
+	}
+
+	guarded.Guard(func(change driver.Change) error {
+		return policy.Evaluate(guards, transitionOf(source, change))
+	})
+
+	return nil
+}
+
+// transitionOf describes a change about to be written by the driver of source
+// to its guards.
+func transitionOf(source models.Source, change driver.Change) policy.Transition {
+	driverName := source.Driver
+	if driverName == models.DriverUnspecified {
+		driverName = models.DriverS3
+	}
+
+	transition := policy.Transition{
+		To:        change.To.String(),
+		Bump:      change.Bump,
+		Modified:  change.Modified,
+		Namespace: source.Namespace,
+		Driver:    driverName,
+		Build:     models.BuildMetadataFromEnv(),
+		Time:      time.Now().UTC(),
+	}
+
+	if change.From != nil {
+		transition.From = change.From.String()
+	}
+
+	return transition
+}
+
+// existingOnCooldown returns the version a put refused for following the last
+// change too soon reports instead of failing, if it is configured to.
+func existingOnCooldown(source models.Source, err error) (semver.Version, bool) {
+	var cooling policy.CoolingDown
+	if !errors.As(err, &cooling) || source.CooldownBehavior != returnExisting {
+		return semver.Version{}, false
+	}
+
+	// a change is only cooling down if it replaces a stored version
+	current, err := semver.Parse(cooling.Transition.From)
+	if err != nil {
+		return semver.Version{}, false
+	}
+
+	fmt.Fprintln(os.Stderr, cooling)
+
+	return current, true
+}
+
+const returnExisting = "return_existing"
+
+// checkNamespace checks the namespace is writable, for changes to reservations
+// that do not write a version for the guards to check.
+func checkNamespace(guards []policy.Guard, namespace string) error {
+	for _, guard := range guards {
+		if namespaces, ok := guard.(policy.Namespaces); ok {
+			return namespaces.Check(namespace)
+		}
+	}
+
+	return nil
+}
+
+// reserverOf returns store if it can reserve versions.
+func reserverOf(store driver.Driver) (driver.Reserver, error) {
+	reserver, ok := store.(driver.Reserver)
+	if !ok {
+		return nil, models.ValidationError{"reservations are only supported by the git driver"}
+	}
+
+	return reserver, nil
+}
+
+// readVersionFile reads the version number in the file at path.
+func readVersionFile(path string) (semver.Version, error) {
+	versionFile, err := os.Open(path)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	defer versionFile.Close()
+
+	var versionStr string
+	_, err = fmt.Fscanf(versionFile, "%s", &versionStr)
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	return semver.Parse(versionStr)
+}
+
+// readBumpFile reads a bump written by the analyze task: one of major, minor,
+// patch, final or none.
+func readBumpFile(path string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	bump := strings.TrimSpace(string(contents))
+
+	switch bump {
+	case "major", "minor", "patch", "final", "none":
+		return bump, nil
+	}
+
+	return "", models.ValidationError{fmt.Sprintf("unknown bump '%s'", bump)}
+}
+
+// requestSource is the source failures are reported with, once the request
+// has been read.
+var requestSource *models.Source
+
+// fatal reports err, both as text and as a JSON failure on stdout for
+// tooling to branch on, and exits.
 func fatal(doing string, err error) {
-	println("error " + doing + ": " + err.Error())
+	tracing.Fail(err)
+
+	message := "error " + doing + ": " + err.Error()
+	println(message)
+
+	json.NewEncoder(os.Stdout).Encode(map[string]driver.Failure{
+		"error": driver.NewFailure("out", requestSource, message, err),
+	})
+
 	os.Exit(1)
 }
@@ -0,0 +1,44 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+)
+
+// Cooldown rejects changes made within Interval of the last one.
+type Cooldown struct {
+	Interval time.Duration
+}
+
+func (cooldown Cooldown) Allow(transition Transition) error {
+	if transition.Modified == nil {
+		return nil
+	}
+
+	elapsed := transition.Time.Sub(*transition.Modified)
+	if elapsed >= cooldown.Interval {
+		return nil
+	}
+
+	return CoolingDown{
+		Transition: transition,
+		Interval:   cooldown.Interval,
+		Remaining:  cooldown.Interval - elapsed,
+	}
+}
+
+// CoolingDown is a change rejected for following the last one too soon.
+type CoolingDown struct {
+	Transition Transition
+	Interval   time.Duration
+	Remaining  time.Duration
+}
+
+func (cooling CoolingDown) Error() string {
+	return fmt.Sprintf(
+		"%s was written less than %s ago; no changes are allowed for another %s",
+		cooling.Transition.From,
+		cooling.Interval,
+		cooling.Remaining.Round(time.Second),
+	)
+}
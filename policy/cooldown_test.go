@@ -0,0 +1,59 @@
+package policy_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/policy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cooldown", func() {
+	var cooldown policy.Cooldown
+	var transition policy.Transition
+	var now time.Time
+
+	BeforeEach(func() {
+		cooldown = policy.Cooldown{Interval: 10 * time.Minute}
+
+		now = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+		transition = policy.Transition{From: "1.4.2", To: "1.4.3", Bump: "patch", Time: now}
+	})
+
+	It("allows changes when the last change's time is unknown", func() {
+		Expect(cooldown.Allow(transition)).To(Succeed())
+	})
+
+	It("allows changes after the interval", func() {
+		modified := now.Add(-10 * time.Minute)
+		transition.Modified = &modified
+
+		Expect(cooldown.Allow(transition)).To(Succeed())
+	})
+
+	It("rejects changes within the interval", func() {
+		modified := now.Add(-2*time.Minute - 500*time.Millisecond)
+		transition.Modified = &modified
+
+		err := cooldown.Allow(transition)
+		Expect(err).To(BeAssignableToTypeOf(policy.CoolingDown{}))
+		Expect(err.(policy.CoolingDown).Remaining).To(Equal(7*time.Minute + 59500*time.Millisecond))
+		Expect(err).To(MatchError("1.4.2 was written less than 10m0s ago; no changes are allowed for another 8m0s"))
+	})
+
+	Describe("FromSource", func() {
+		It("configures a cooldown from min_bump_interval", func() {
+			guards, err := policy.FromSource(models.Source{MinBumpInterval: "1h"}, http.DefaultClient)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guards).To(Equal([]policy.Guard{policy.Cooldown{Interval: time.Hour}}))
+		})
+
+		It("rejects invalid intervals", func() {
+			_, err := policy.FromSource(models.Source{MinBumpInterval: "soon"}, http.DefaultClient)
+			Expect(err).To(MatchError(ContainSubstring("invalid min_bump_interval (soon)")))
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a five-field cron expression: minute, hour, day of month,
+// month and day of week. Each field is *, or a comma-separated list of
+// values and ranges, optionally with a /step. Days of the week run from 0
+// (Sunday) to 6, and 7 is also Sunday.
+type Schedule struct {
+	Expression string
+
+	minutes, hours, days, months, weekdays []bool
+
+	// as in cron, if both days of the month and of the week are restricted,
+	// matching either is enough
+	anyDay, anyWeekday bool
+}
+
+func ParseSchedule(expression string) (Schedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("cron expression '%s' must have 5 fields", expression)
+	}
+
+	schedule := Schedule{Expression: expression}
+
+	var err error
+	for i, field := range []struct {
+		set      *[]bool
+		min, max int
+	}{
+		{&schedule.minutes, 0, 59},
+		{&schedule.hours, 0, 23},
+		{&schedule.days, 1, 31},
+		{&schedule.months, 1, 12},
+		{&schedule.weekdays, 0, 7},
+	} {
+		*field.set, err = parseField(fields[i], field.min, field.max)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("cron expression '%s': %s", expression, err)
+		}
+	}
+
+	if schedule.weekdays[7] {
+		schedule.weekdays[0] = true
+	}
+
+	schedule.anyDay = fields[2] == "*"
+	schedule.anyWeekday = fields[4] == "*"
+
+	return schedule, nil
+}
+
+// Matches reports whether the minute containing t is in the schedule.
+func (schedule Schedule) Matches(t time.Time) bool {
+	if !schedule.minutes[t.Minute()] || !schedule.hours[t.Hour()] || !schedule.months[int(t.Month())] {
+		return false
+	}
+
+	day := schedule.days[t.Day()]
+	weekday := schedule.weekdays[int(t.Weekday())]
+
+	if schedule.anyDay || schedule.anyWeekday {
+		return day && weekday
+	}
+
+	return day || weekday
+}
+
+func parseField(field string, min int, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if slash := strings.Index(part, "/"); slash != -1 {
+			var err error
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in '%s'", part)
+			}
+
+			part = part[:slash]
+		}
+
+		first, last := min, max
+
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			var err error
+			first, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value '%s'", bounds[0])
+			}
+
+			last = first
+			if len(bounds) == 2 {
+				last, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid value '%s'", bounds[1])
+				}
+			} else if step > 1 {
+				// "5/15" means every 15 from 5
+				last = max
+			}
+		}
+
+		if first < min || last > max || first > last {
+			return nil, fmt.Errorf("'%s' is out of range %d-%d", part, min, max)
+		}
+
+		for value := first; value <= last; value += step {
+			set[value] = true
+		}
+	}
+
+	return set, nil
+}
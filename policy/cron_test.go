@@ -0,0 +1,65 @@
+package policy_test
+
+import (
+	"time"
+
+	"github.com/concourse/semver-resource/policy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schedule", func() {
+	at := func(date string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04 Mon", date)
+		Expect(err).NotTo(HaveOccurred())
+		return t
+	}
+
+	matches := func(expression string, date string) bool {
+		schedule, err := policy.ParseSchedule(expression)
+		Expect(err).NotTo(HaveOccurred())
+		return schedule.Matches(at(date))
+	}
+
+	It("matches every minute with wildcards", func() {
+		Expect(matches("* * * * *", "2026-10-16 17:30 Fri")).To(BeTrue())
+	})
+
+	It("matches ranges of hours on days of the week", func() {
+		Expect(matches("* 16-23 * * 5", "2026-10-16 16:00 Fri")).To(BeTrue())
+		Expect(matches("* 16-23 * * 5", "2026-10-16 23:59 Fri")).To(BeTrue())
+		Expect(matches("* 16-23 * * 5", "2026-10-16 15:59 Fri")).To(BeFalse())
+		Expect(matches("* 16-23 * * 5", "2026-10-17 17:00 Sat")).To(BeFalse())
+	})
+
+	It("matches lists and steps", func() {
+		Expect(matches("0,30 * * * *", "2026-10-16 17:30 Fri")).To(BeTrue())
+		Expect(matches("0,30 * * * *", "2026-10-16 17:31 Fri")).To(BeFalse())
+		Expect(matches("*/15 * * * *", "2026-10-16 17:45 Fri")).To(BeTrue())
+		Expect(matches("5/15 * * * *", "2026-10-16 17:50 Fri")).To(BeTrue())
+		Expect(matches("5/15 * * * *", "2026-10-16 17:45 Fri")).To(BeFalse())
+	})
+
+	It("treats 7 as Sunday", func() {
+		Expect(matches("* * * * 7", "2026-10-18 12:00 Sun")).To(BeTrue())
+	})
+
+	It("matches either restricted day of the month or of the week", func() {
+		Expect(matches("* * 1 * 1", "2026-10-01 12:00 Thu")).To(BeTrue())
+		Expect(matches("* * 1 * 1", "2026-10-05 12:00 Mon")).To(BeTrue())
+		Expect(matches("* * 1 * 1", "2026-10-06 12:00 Tue")).To(BeFalse())
+	})
+
+	It("matches whole months", func() {
+		Expect(matches("* * * 12 *", "2026-12-24 12:00 Thu")).To(BeTrue())
+		Expect(matches("* * * 12 *", "2026-11-24 12:00 Tue")).To(BeFalse())
+	})
+
+	It("rejects malformed expressions", func() {
+		for _, expression := range []string{"* * * *", "60 * * * *", "* * 0 * *", "* 5-3 * * *", "*/0 * * * *", "x * * * *"} {
+			_, err := policy.ParseSchedule(expression)
+			Expect(err).To(HaveOccurred(), expression)
+		}
+	})
+})
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	// freeze windows may be in any timezone, even if the image has no zoneinfo
+	_ "time/tzdata"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// Freeze rejects changes made during any of its windows.
+type Freeze struct {
+	Windows  []FreezeWindow
+	Location *time.Location
+}
+
+// A FreezeWindow is either a date range, from From until To, or every minute
+// matching a Schedule.
+type FreezeWindow struct {
+	From     time.Time
+	To       time.Time
+	Schedule *Schedule
+	Reason   string
+}
+
+var dateFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func NewFreeze(options models.FreezeOptions) (*Freeze, error) {
+	timezone := options.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid freeze timezone (%s): %s", timezone, err)
+	}
+
+	freeze := &Freeze{Location: location}
+
+	for i, options := range options.Windows {
+		window := FreezeWindow{Reason: options.Reason}
+
+		switch {
+		case options.Cron != "" && (options.From != "" || options.To != ""):
+			return nil, fmt.Errorf("freeze window %d must have either a cron expression or from and to, not both", i+1)
+
+		case options.Cron != "":
+			schedule, err := ParseSchedule(options.Cron)
+			if err != nil {
+				return nil, fmt.Errorf("freeze window %d: %s", i+1, err)
+			}
+
+			window.Schedule = &schedule
+
+		case options.From != "" && options.To != "":
+			window.From, err = parseDate(options.From, location, false)
+			if err != nil {
+				return nil, fmt.Errorf("freeze window %d: %s", i+1, err)
+			}
+
+			window.To, err = parseDate(options.To, location, true)
+			if err != nil {
+				return nil, fmt.Errorf("freeze window %d: %s", i+1, err)
+			}
+
+			if !window.To.After(window.From) {
+				return nil, fmt.Errorf("freeze window %d ends before it starts", i+1)
+			}
+
+		default:
+			return nil, fmt.Errorf("freeze window %d must have a cron expression or both from and to", i+1)
+		}
+
+		freeze.Windows = append(freeze.Windows, window)
+	}
+
+	return freeze, nil
+}
+
+// parseDate parses a date or time in location. A date alone is the start of
+// that day, or its end if it ends a window, so that ranges of dates are
+// inclusive.
+func parseDate(value string, location *time.Location, end bool) (time.Time, error) {
+	for _, format := range dateFormats {
+		parsed, err := time.ParseInLocation(format, value, location)
+		if err != nil {
+			continue
+		}
+
+		if format == "2006-01-02" && end {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+
+		return parsed, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date '%s'", value)
+}
+
+func (freeze *Freeze) Allow(transition Transition) error {
+	now := transition.Time.In(freeze.Location)
+
+	for _, window := range freeze.Windows {
+		if window.Schedule != nil {
+			if window.Schedule.Matches(now) {
+				return Frozen{Transition: transition, Window: window}
+			}
+
+			continue
+		}
+
+		if !now.Before(window.From) && now.Before(window.To) {
+			return Frozen{Transition: transition, Window: window}
+		}
+	}
+
+	return nil
+}
+
+// Frozen is a change rejected for being made during a freeze window.
+type Frozen struct {
+	Transition Transition
+	Window     FreezeWindow
+}
+
+func (frozen Frozen) Error() string {
+	var message string
+	if frozen.Window.Schedule != nil {
+		message = fmt.Sprintf("changes are frozen (%s)", frozen.Window.Schedule.Expression)
+	} else {
+		message = fmt.Sprintf("changes are frozen until %s", frozen.Window.To.Format("2006-01-02 15:04 MST"))
+	}
+
+	if frozen.Window.Reason != "" {
+		message += ": " + frozen.Window.Reason
+	}
+
+	return message
+}
@@ -0,0 +1,86 @@
+package policy_test
+
+import (
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/policy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Freeze", func() {
+	var options models.FreezeOptions
+
+	BeforeEach(func() {
+		options = models.FreezeOptions{
+			Timezone: "Europe/London",
+			Windows: []models.FreezeWindow{
+				{From: "2026-12-21", To: "2027-01-03", Reason: "Holidays"},
+				{Cron: "* 16-23 * * 5", Reason: "No Friday evening releases"},
+			},
+		}
+	})
+
+	allow := func(t time.Time) error {
+		freeze, err := policy.NewFreeze(options)
+		Expect(err).NotTo(HaveOccurred())
+
+		return freeze.Allow(policy.Transition{From: "1.4.2", To: "1.5.0", Bump: "minor", Time: t})
+	}
+
+	london, _ := time.LoadLocation("Europe/London")
+
+	It("allows changes outside the windows", func() {
+		Expect(allow(time.Date(2026, 12, 20, 23, 59, 0, 0, london))).To(Succeed())
+		Expect(allow(time.Date(2027, 1, 4, 0, 0, 0, 0, london))).To(Succeed())
+		Expect(allow(time.Date(2026, 10, 16, 15, 59, 0, 0, london))).To(Succeed())
+	})
+
+	It("rejects changes during a date range, including its last day", func() {
+		Expect(allow(time.Date(2026, 12, 21, 0, 0, 0, 0, london))).To(MatchError("changes are frozen until 2027-01-04 00:00 GMT: Holidays"))
+		Expect(allow(time.Date(2027, 1, 3, 23, 59, 0, 0, london))).To(HaveOccurred())
+	})
+
+	It("rejects changes matching a cron expression in the timezone", func() {
+		// 15:30 UTC is 16:30 in London during summer time
+		Expect(allow(time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC))).To(MatchError("changes are frozen (* 16-23 * * 5): No Friday evening releases"))
+	})
+
+	It("uses times of day in ranges", func() {
+		options.Windows = []models.FreezeWindow{{From: "2026-10-16T17:00", To: "2026-10-16T18:00"}}
+
+		Expect(allow(time.Date(2026, 10, 16, 17, 30, 0, 0, london))).To(MatchError("changes are frozen until 2026-10-16 18:00 BST"))
+		Expect(allow(time.Date(2026, 10, 16, 18, 0, 0, 0, london))).To(Succeed())
+	})
+
+	It("defaults to UTC", func() {
+		options.Timezone = ""
+		options.Windows = []models.FreezeWindow{{From: "2026-10-16T17:00", To: "2026-10-16T18:00"}}
+
+		Expect(allow(time.Date(2026, 10, 16, 17, 30, 0, 0, time.UTC))).To(HaveOccurred())
+		Expect(allow(time.Date(2026, 10, 16, 17, 30, 0, 0, london))).To(Succeed())
+	})
+
+	It("rejects invalid windows", func() {
+		for _, window := range []models.FreezeWindow{
+			{},
+			{From: "2026-12-21"},
+			{From: "2026-12-21", To: "2026-12-20"},
+			{From: "21/12/2026", To: "2026-12-22"},
+			{Cron: "* * *"},
+			{Cron: "* * * * *", From: "2026-12-21", To: "2026-12-22"},
+		} {
+			_, err := policy.NewFreeze(models.FreezeOptions{Windows: []models.FreezeWindow{window}})
+			Expect(err).To(HaveOccurred(), "%#v", window)
+		}
+	})
+
+	It("rejects unknown timezones", func() {
+		options.Timezone = "Mars/Olympus_Mons"
+
+		_, err := policy.NewFreeze(options)
+		Expect(err).To(MatchError(HavePrefix("invalid freeze timezone (Mars/Olympus_Mons)")))
+	})
+})
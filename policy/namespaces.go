@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Namespaces rejects changes to versions outside the namespaces matching
+// Writable. A pattern matching a namespace also matches those nested within
+// it, so payments/* allows payments/api and payments/api/eu.
+type Namespaces struct {
+	Writable []string
+}
+
+// NewNamespaces checks every pattern is well-formed.
+func NewNamespaces(writable []string) (Namespaces, error) {
+	for _, pattern := range writable {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return Namespaces{}, fmt.Errorf("invalid writable namespace (%s): %s", pattern, err)
+		}
+	}
+
+	return Namespaces{Writable: writable}, nil
+}
+
+func (namespaces Namespaces) Allow(transition Transition) error {
+	return namespaces.Check(transition.Namespace)
+}
+
+// Check rejects writing to namespace, for writes that do not change a version,
+// like abandoning a reservation.
+func (namespaces Namespaces) Check(namespace string) error {
+	if namespace != "" && namespaces.Writable != nil {
+		segments := strings.Split(namespace, "/")
+
+		for i := range segments {
+			within := strings.Join(segments[:i+1], "/")
+
+			for _, pattern := range namespaces.Writable {
+				if matched, _ := path.Match(pattern, within); matched {
+					return nil
+				}
+			}
+		}
+	}
+
+	return ReadOnlyNamespace{
+		Namespace: namespace,
+		Writable:  namespaces.Writable,
+	}
+}
+
+// ReadOnlyNamespace is a change rejected for being outside the writable
+// namespaces.
+type ReadOnlyNamespace struct {
+	Namespace string
+	Writable  []string
+}
+
+func (readOnly ReadOnlyNamespace) Error() string {
+	namespace := "outside of a namespace"
+	if readOnly.Namespace != "" {
+		namespace = "in namespace " + readOnly.Namespace
+	}
+
+	if len(readOnly.Writable) == 0 {
+		return fmt.Sprintf("versions %s may not be changed; no namespaces are writable", namespace)
+	}
+
+	return fmt.Sprintf(
+		"versions %s may not be changed; writable namespaces are %s",
+		namespace,
+		strings.Join(readOnly.Writable, ", "),
+	)
+}
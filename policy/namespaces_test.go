@@ -0,0 +1,60 @@
+package policy_test
+
+import (
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/policy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Namespaces", func() {
+	allow := func(writable []string, namespace string) error {
+		namespaces, err := policy.NewNamespaces(writable)
+		Expect(err).NotTo(HaveOccurred())
+
+		return namespaces.Allow(policy.Transition{From: "1.4.2", To: "1.5.0", Bump: "minor", Namespace: namespace})
+	}
+
+	It("allows changes in matching namespaces", func() {
+		Expect(allow([]string{"payments/*"}, "payments/api")).To(Succeed())
+		Expect(allow([]string{"search", "payments/*"}, "search")).To(Succeed())
+	})
+
+	It("allows changes in namespaces nested within a match", func() {
+		Expect(allow([]string{"payments/*"}, "payments/api/eu")).To(Succeed())
+		Expect(allow([]string{"payments"}, "payments/api")).To(Succeed())
+	})
+
+	It("rejects changes in other namespaces", func() {
+		Expect(allow([]string{"payments/*"}, "payments")).To(MatchError("versions in namespace payments may not be changed; writable namespaces are payments/*"))
+		Expect(allow([]string{"payments/*", "search"}, "identity/api")).To(MatchError("versions in namespace identity/api may not be changed; writable namespaces are payments/*, search"))
+	})
+
+	It("rejects changes outside of a namespace", func() {
+		Expect(allow([]string{"*"}, "")).To(MatchError("versions outside of a namespace may not be changed; writable namespaces are *"))
+	})
+
+	It("rejects every change when nothing is writable", func() {
+		Expect(allow([]string{}, "payments/api")).To(MatchError("versions in namespace payments/api may not be changed; no namespaces are writable"))
+	})
+
+	It("checks namespaces without a transition", func() {
+		namespaces, err := policy.NewNamespaces([]string{"payments/*"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(namespaces.Check("payments/api")).To(Succeed())
+		Expect(namespaces.Check("search")).To(MatchError("versions in namespace search may not be changed; writable namespaces are payments/*"))
+	})
+
+	It("rejects malformed patterns", func() {
+		_, err := policy.NewNamespaces([]string{"payments/["})
+		Expect(err).To(MatchError(HavePrefix("invalid writable namespace (payments/[)")))
+	})
+
+	It("is configured by writable_namespaces", func() {
+		guards, err := policy.FromSource(models.Source{WritableNamespaces: []string{"payments/*"}}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(guards).To(Equal([]policy.Guard{policy.Namespaces{Writable: []string{"payments/*"}}}))
+	})
+})
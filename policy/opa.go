@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+const DefaultDecision = "semver/allow"
+
+// OPA asks an Open Policy Agent server for a decision on every transition.
+//
+// The transition is the input. The decision may be a boolean, a set of
+// reasons to deny (allowing if empty), or an object with an "allow" boolean
+// and optionally "reasons".
+type OPA struct {
+	URL      string
+	Decision string
+	Headers  map[string]string
+
+	client *http.Client
+}
+
+func NewOPA(options models.PolicyOptions, client *http.Client) *OPA {
+	decision := strings.Trim(options.Decision, "/")
+	if decision == "" {
+		decision = DefaultDecision
+	}
+
+	return &OPA{
+		URL:      strings.TrimRight(options.URL, "/"),
+		Decision: decision,
+		Headers:  options.Headers,
+
+		client: client,
+	}
+}
+
+func (opa *OPA) Allow(transition Transition) error {
+	result, err := opa.query(transition)
+	if err != nil {
+		return fmt.Errorf("evaluating policy: %w", err)
+	}
+
+	allowed, reasons, err := interpret(result)
+	if err != nil {
+		return fmt.Errorf("evaluating policy: %w", err)
+	}
+
+	if !allowed {
+		return Rejection{Transition: transition, Reasons: reasons}
+	}
+
+	return nil
+}
+
+// query asks an OPA server through its Data API.
+func (opa *OPA) query(transition Transition) (json.RawMessage, error) {
+	payload, err := json.Marshal(map[string]interface{}{"input": transition})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", opa.URL+"/v1/data/"+opa.Decision, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	for name, value := range opa.Headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := opa.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("opa responded with %s", response.Status)
+	}
+
+	var body struct {
+		Result json.RawMessage `json:"result"`
+	}
+
+	err = json.NewDecoder(response.Body).Decode(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	return body.Result, nil
+}
+
+// interpret turns a decision into whether the transition is allowed and, if
+// not, why. An undefined decision denies.
+func interpret(result json.RawMessage) (bool, []string, error) {
+	if len(result) == 0 || string(result) == "null" {
+		return false, []string{"policy decision is undefined"}, nil
+	}
+
+	var allowed bool
+	if json.Unmarshal(result, &allowed) == nil {
+		return allowed, nil, nil
+	}
+
+	var reasons []string
+	if json.Unmarshal(result, &reasons) == nil {
+		return len(reasons) == 0, reasons, nil
+	}
+
+	var decision struct {
+		Allow   *bool    `json:"allow"`
+		Reasons []string `json:"reasons"`
+	}
+
+	err := json.Unmarshal(result, &decision)
+	if err != nil || decision.Allow == nil {
+		return false, nil, fmt.Errorf("unexpected decision: %s", result)
+	}
+
+	return *decision.Allow, decision.Reasons, nil
+}
@@ -0,0 +1,115 @@
+package policy_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/policy"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OPA", func() {
+	var transition policy.Transition
+
+	BeforeEach(func() {
+		transition = policy.Transition{
+			From:   "1.4.2",
+			To:     "2.0.0",
+			Bump:   "major",
+			Driver: models.DriverGit,
+		}
+	})
+
+	Describe("querying a server", func() {
+		var server *httptest.Server
+		var decision string
+		var request *http.Request
+		var input policy.Transition
+
+		BeforeEach(func() {
+			decision = `true`
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request = r
+
+				var body struct {
+					Input policy.Transition `json:"input"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				input = body.Input
+
+				if decision == "" {
+					w.Write([]byte(`{}`))
+				} else {
+					w.Write([]byte(`{"result": ` + decision + `}`))
+				}
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		allow := func(options models.PolicyOptions) error {
+			options.URL = server.URL
+
+			return policy.NewOPA(options, http.DefaultClient).Allow(transition)
+		}
+
+		It("queries the default decision with the transition as input", func() {
+			Expect(allow(models.PolicyOptions{Headers: map[string]string{"Authorization": "Bearer token"}})).To(Succeed())
+			Expect(request.URL.Path).To(Equal("/v1/data/semver/allow"))
+			Expect(request.Header.Get("Authorization")).To(Equal("Bearer token"))
+			Expect(input).To(Equal(transition))
+		})
+
+		It("queries the configured decision", func() {
+			Expect(allow(models.PolicyOptions{Decision: "/releases/bump/"})).To(Succeed())
+			Expect(request.URL.Path).To(Equal("/v1/data/releases/bump"))
+		})
+
+		It("rejects transitions which are not allowed", func() {
+			decision = `false`
+			Expect(allow(models.PolicyOptions{})).To(MatchError("major to 2.0.0 rejected by policy"))
+		})
+
+		It("rejects transitions with reasons to deny", func() {
+			decision = `["major bumps need a release window", "no weekend releases"]`
+			Expect(allow(models.PolicyOptions{})).To(MatchError("major to 2.0.0 rejected by policy: major bumps need a release window; no weekend releases"))
+		})
+
+		It("allows transitions without reasons to deny", func() {
+			decision = `[]`
+			Expect(allow(models.PolicyOptions{})).To(Succeed())
+		})
+
+		It("understands decision objects", func() {
+			decision = `{"allow": false, "reasons": ["frozen"]}`
+			Expect(allow(models.PolicyOptions{})).To(MatchError("major to 2.0.0 rejected by policy: frozen"))
+
+			decision = `{"allow": true}`
+			Expect(allow(models.PolicyOptions{})).To(Succeed())
+		})
+
+		It("rejects transitions when the decision is undefined", func() {
+			decision = ""
+			Expect(allow(models.PolicyOptions{})).To(MatchError("major to 2.0.0 rejected by policy: policy decision is undefined"))
+		})
+
+		It("fails on unexpected decisions", func() {
+			decision = `42`
+			Expect(allow(models.PolicyOptions{})).To(MatchError("evaluating policy: unexpected decision: 42"))
+		})
+	})
+
+	Describe("Rejection", func() {
+		It("describes rejected sets", func() {
+			transition.Bump = "set"
+			Expect(policy.Rejection{Transition: transition}.Error()).To(Equal("setting 2.0.0 rejected by policy"))
+		})
+	})
+})
@@ -0,0 +1,112 @@
+// Package policy decides whether a version may be written.
+package policy
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// Transition is a proposed change of version.
+type Transition struct {
+	// From is the current version, or empty if none is stored.
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+
+	// Bump describes how To was derived, or is "set" for an explicit version.
+	Bump string `json:"bump"`
+
+	// Modified is when the current version was written, if known.
+	Modified *time.Time `json:"modified,omitempty"`
+
+	// Namespace is the namespace of the version, if any.
+	Namespace string `json:"namespace,omitempty"`
+
+	Driver models.Driver        `json:"driver"`
+	Build  models.BuildMetadata `json:"build"`
+	Time   time.Time            `json:"time"`
+}
+
+// A Guard rejects transitions it does not allow by returning an error
+// explaining why.
+type Guard interface {
+	Allow(Transition) error
+}
+
+// FromSource returns a guard for every policy configured in source. Problems
+// with the configuration are returned as a models.ValidationError.
+func FromSource(source models.Source, client *http.Client) ([]Guard, error) {
+	guards := []Guard{}
+
+	if source.WritableNamespaces != nil {
+		namespaces, err := NewNamespaces(source.WritableNamespaces)
+		if err != nil {
+			return nil, models.ValidationError{err.Error()}
+		}
+
+		guards = append(guards, namespaces)
+	}
+
+	if source.Policy.URL != "" {
+		guards = append(guards, NewOPA(source.Policy, client))
+	}
+
+	if len(source.Freeze.Windows) > 0 {
+		freeze, err := NewFreeze(source.Freeze)
+		if err != nil {
+			return nil, models.ValidationError{err.Error()}
+		}
+
+		guards = append(guards, freeze)
+	}
+
+	if source.MinBumpInterval != "" {
+		interval, err := time.ParseDuration(source.MinBumpInterval)
+		if err != nil {
+			return nil, models.ValidationError{fmt.Sprintf("invalid min_bump_interval (%s): %s", source.MinBumpInterval, err)}
+		}
+
+		guards = append(guards, Cooldown{Interval: interval})
+	}
+
+	return guards, nil
+}
+
+// Evaluate returns the first rejection of transition by guards.
+func Evaluate(guards []Guard, transition Transition) error {
+	for _, guard := range guards {
+		err := guard.Allow(transition)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rejection is a transition disallowed by policy.
+type Rejection struct {
+	Transition Transition
+	Reasons    []string
+}
+
+func (rejection Rejection) Error() string {
+	message := fmt.Sprintf("%s to %s rejected by policy", rejection.Transition.Bump, rejection.Transition.To)
+	if rejection.Transition.Bump == "set" {
+		message = fmt.Sprintf("setting %s rejected by policy", rejection.Transition.To)
+	}
+
+	for i, reason := range rejection.Reasons {
+		if i == 0 {
+			message += ": "
+		} else {
+			message += "; "
+		}
+
+		message += reason
+	}
+
+	return message
+}
@@ -0,0 +1,193 @@
+// Package provenance describes how a version was produced as an in-toto
+// statement carrying a SLSA v1 provenance predicate.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+const (
+	StatementType = "https://in-toto.io/Statement/v1"
+	PredicateType = "https://slsa.dev/provenance/v1"
+
+	// BuildType identifies the semantics of the parameters recorded for a
+	// put.
+	BuildType = "https://github.com/concourse/semver-resource/put@v1"
+)
+
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     Predicate            `json:"predicate"`
+}
+
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]string    `json:"externalParameters"`
+	InternalParameters   map[string]string    `json:"internalParameters,omitempty"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+type RunDetails struct {
+	Builder  Builder     `json:"builder"`
+	Metadata RunMetadata `json:"metadata"`
+}
+
+type Builder struct {
+	ID string `json:"id"`
+}
+
+type RunMetadata struct {
+	InvocationID string     `json:"invocationId,omitempty"`
+	StartedOn    *time.Time `json:"startedOn,omitempty"`
+	FinishedOn   *time.Time `json:"finishedOn,omitempty"`
+}
+
+// Invocation is everything known about a put that wrote a version.
+type Invocation struct {
+	// Name is the name of the file, key or item the version is stored in,
+	// and Location a URI for it.
+	Name     string
+	Location string
+	Driver   models.Driver
+
+	From string
+	To   string
+	Bump string
+
+	Params models.OutParams
+
+	BuilderID string
+	Build     models.BuildMetadata
+
+	StartedOn  time.Time
+	FinishedOn time.Time
+}
+
+// Generate returns the provenance of the version written by invocation. Its
+// subject is the version, identified by the digest of its string form.
+func Generate(invocation Invocation) Statement {
+	external := map[string]string{}
+	for name, value := range map[string]string{
+		"bump":      invocation.Params.Bump,
+		"pre":       invocation.Params.Pre,
+		"file":      invocation.Params.File,
+		"bump_file": invocation.Params.BumpFile,
+	} {
+		if value != "" {
+			external[name] = value
+		}
+	}
+
+	if invocation.Bump != "" {
+		external["applied_bump"] = invocation.Bump
+	}
+
+	driver := invocation.Driver
+	if driver == models.DriverUnspecified {
+		driver = models.DriverS3
+	}
+
+	definition := BuildDefinition{
+		BuildType:          BuildType,
+		ExternalParameters: external,
+		InternalParameters: map[string]string{"driver": string(driver)},
+	}
+
+	if invocation.From != "" {
+		definition.ResolvedDependencies = []ResourceDescriptor{{
+			Name:   invocation.Name + "@" + invocation.From,
+			URI:    invocation.Location,
+			Digest: Digest(invocation.From),
+		}}
+	}
+
+	builderID := invocation.BuilderID
+	if builderID == "" {
+		builderID = BuildType
+	}
+
+	invocationID := invocation.Build.URL
+	if invocationID == "" {
+		invocationID = invocation.Build.ID
+	}
+
+	metadata := RunMetadata{InvocationID: invocationID}
+
+	if !invocation.StartedOn.IsZero() {
+		startedOn := invocation.StartedOn.UTC()
+		metadata.StartedOn = &startedOn
+	}
+
+	if !invocation.FinishedOn.IsZero() {
+		finishedOn := invocation.FinishedOn.UTC()
+		metadata.FinishedOn = &finishedOn
+	}
+
+	return Statement{
+		Type: StatementType,
+		Subject: []ResourceDescriptor{{
+			Name:   invocation.Name,
+			URI:    invocation.Location,
+			Digest: Digest(invocation.To),
+		}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: definition,
+			RunDetails: RunDetails{
+				Builder:  Builder{ID: builderID},
+				Metadata: metadata,
+			},
+		},
+	}
+}
+
+// Digest identifies a version by the SHA-256 digest of its string form.
+func Digest(version string) map[string]string {
+	sum := sha256.Sum256([]byte(version))
+	return map[string]string{"sha256": hex.EncodeToString(sum[:])}
+}
+
+// StoredName is the name an attestation is stored under, next to the version
+// stored as versionName.
+func StoredName(options models.ProvenanceOptions, versionName string) string {
+	if options.Name != "" {
+		return options.Name
+	}
+
+	return versionName + ".intoto.json"
+}
+
+// Describes reports whether statement is the provenance of version.
+func (statement Statement) Describes(version string) bool {
+	digest := Digest(version)["sha256"]
+
+	for _, subject := range statement.Subject {
+		if subject.Digest["sha256"] == digest {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (statement Statement) Marshal() ([]byte, error) {
+	return json.MarshalIndent(statement, "", "  ")
+}
@@ -0,0 +1,101 @@
+package provenance_test
+
+import (
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/provenance"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var invocation provenance.Invocation
+
+	BeforeEach(func() {
+		invocation = provenance.Invocation{
+			Name:     "version",
+			Location: "s3://versions/version",
+
+			From: "1.4.2",
+			To:   "1.5.0",
+			Bump: "minor",
+
+			Params: models.OutParams{Bump: "minor"},
+
+			BuilderID: "https://ci.example.com",
+			Build: models.BuildMetadata{
+				ID:  "1234",
+				URL: "https://ci.example.com/builds/1234",
+			},
+
+			StartedOn:  time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+			FinishedOn: time.Date(2026, 10, 15, 12, 0, 5, 0, time.UTC),
+		}
+	})
+
+	It("describes the new version", func() {
+		marshalled, err := provenance.Generate(invocation).Marshal()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(marshalled).To(MatchJSON(`{
+			"_type": "https://in-toto.io/Statement/v1",
+			"subject": [{
+				"name": "version",
+				"uri": "s3://versions/version",
+				"digest": {"sha256": "1f4dc096d58f7d21e3875671aee6f29b120ab84218fa47db2cb53bc9eb5b4dac"}
+			}],
+			"predicateType": "https://slsa.dev/provenance/v1",
+			"predicate": {
+				"buildDefinition": {
+					"buildType": "https://github.com/concourse/semver-resource/put@v1",
+					"externalParameters": {"bump": "minor", "applied_bump": "minor"},
+					"internalParameters": {"driver": "s3"},
+					"resolvedDependencies": [{
+						"name": "version@1.4.2",
+						"uri": "s3://versions/version",
+						"digest": {"sha256": "` + provenance.Digest("1.4.2")["sha256"] + `"}
+					}]
+				},
+				"runDetails": {
+					"builder": {"id": "https://ci.example.com"},
+					"metadata": {
+						"invocationId": "https://ci.example.com/builds/1234",
+						"startedOn": "2026-10-15T12:00:00Z",
+						"finishedOn": "2026-10-15T12:00:05Z"
+					}
+				}
+			}
+		}`))
+	})
+
+	It("has no dependencies when there was no previous version", func() {
+		invocation.From = ""
+
+		statement := provenance.Generate(invocation)
+		Expect(statement.Predicate.BuildDefinition.ResolvedDependencies).To(BeEmpty())
+	})
+
+	It("falls back to the build type as the builder", func() {
+		invocation.BuilderID = ""
+
+		statement := provenance.Generate(invocation)
+		Expect(statement.Predicate.RunDetails.Builder.ID).To(Equal(provenance.BuildType))
+	})
+
+	Describe("Describes", func() {
+		It("matches the subject's version", func() {
+			statement := provenance.Generate(invocation)
+			Expect(statement.Describes("1.5.0")).To(BeTrue())
+			Expect(statement.Describes("1.4.2")).To(BeFalse())
+		})
+	})
+
+	Describe("StoredName", func() {
+		It("defaults to a name next to the version", func() {
+			Expect(provenance.StoredName(models.ProvenanceOptions{}, "version")).To(Equal("version.intoto.json"))
+			Expect(provenance.StoredName(models.ProvenanceOptions{Name: "attestation"}, "version")).To(Equal("attestation"))
+		})
+	})
+})
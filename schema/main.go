@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// Configuration is a resource's source together with the params of the
+// steps using it, as written in a pipeline.
+type Configuration struct {
+	Source    models.Source    `json:"source"`
+	GetParams models.InParams  `json:"get_params"`
+	PutParams models.OutParams `json:"put_params"`
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		validate()
+		return
+	}
+
+	if len(os.Args) > 1 {
+		println("usage: " + os.Args[0] + " [validate]")
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(map[string]interface{}{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"definitions": map[string]*models.Schema{
+			"source":     models.SchemaOf(models.Source{}),
+			"get_params": models.SchemaOf(models.InParams{}),
+			"put_params": models.SchemaOf(models.OutParams{}),
+		},
+	})
+}
+
+func validate() {
+	var config Configuration
+	err := json.NewDecoder(os.Stdin).Decode(&config)
+	if err != nil {
+		fatal("reading configuration", err)
+	}
+
+	valid := true
+	for _, part := range []struct {
+		name   string
+		config interface{}
+	}{
+		{"source", config.Source},
+		{"get_params", config.GetParams},
+		{"put_params", config.PutParams},
+	} {
+		err := models.Validate(part.config)
+		if problems, ok := err.(models.ValidationError); ok {
+			valid = false
+			for _, problem := range problems {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", part.name, problem)
+			}
+		}
+	}
+
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+func fatal(doing string, err error) {
+	println("error " + doing + ": " + err.Error())
+	os.Exit(1)
+}
@@ -0,0 +1,222 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const DefaultFulcioURL = "https://fulcio.sigstore.dev"
+const DefaultRekorURL = "https://rekor.sigstore.dev"
+
+// KeylessSigner signs with an ephemeral key, certified by Fulcio for the
+// identity in an OIDC token, and records each signature in the Rekor
+// transparency log.
+type KeylessSigner struct {
+	FulcioURL     string
+	RekorURL      string
+	IdentityToken string
+
+	client *http.Client
+}
+
+func NewKeylessSigner(fulcioURL string, rekorURL string, identityToken string, client *http.Client) *KeylessSigner {
+	return &KeylessSigner{
+		FulcioURL:     fulcioURL,
+		RekorURL:      rekorURL,
+		IdentityToken: identityToken,
+
+		client: client,
+	}
+}
+
+func (signer *KeylessSigner) Sign(payload []byte) (Signature, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Signature{}, err
+	}
+
+	certificate, err := signer.certify(key)
+	if err != nil {
+		return Signature{}, fmt.Errorf("requesting signing certificate: %w", err)
+	}
+
+	signature, err := sign(key, payload)
+	if err != nil {
+		return Signature{}, err
+	}
+
+	result := Signature{
+		Signature:   encode(signature),
+		Certificate: certificate,
+	}
+
+	err = signer.upload(payload, result)
+	if err != nil {
+		return Signature{}, fmt.Errorf("uploading to transparency log: %w", err)
+	}
+
+	return result, nil
+}
+
+type fulcioRequest struct {
+	Credentials struct {
+		OIDCIdentityToken string `json:"oidcIdentityToken"`
+	} `json:"credentials"`
+	PublicKeyRequest struct {
+		PublicKey struct {
+			Algorithm string `json:"algorithm"`
+			Content   string `json:"content"`
+		} `json:"publicKey"`
+		ProofOfPossession []byte `json:"proofOfPossession"`
+	} `json:"publicKeyRequest"`
+}
+
+type fulcioChain struct {
+	Chain struct {
+		Certificates []string `json:"certificates"`
+	} `json:"chain"`
+}
+
+type fulcioResponse struct {
+	EmbeddedSCT *fulcioChain `json:"signedCertificateEmbeddedSct"`
+	DetachedSCT *fulcioChain `json:"signedCertificateDetachedSct"`
+}
+
+// certify requests a certificate for key from Fulcio, returning the
+// PEM-encoded leaf certificate.
+func (signer *KeylessSigner) certify(key *ecdsa.PrivateKey) ([]byte, error) {
+	subject, err := tokenSubject(signer.IdentityToken)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := sign(key, []byte(subject))
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := publicKeyPEM(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var request fulcioRequest
+	request.Credentials.OIDCIdentityToken = signer.IdentityToken
+	request.PublicKeyRequest.PublicKey.Algorithm = "ECDSA"
+	request.PublicKeyRequest.PublicKey.Content = string(publicKey)
+	request.PublicKeyRequest.ProofOfPossession = proof
+
+	var response fulcioResponse
+	err = signer.post(signer.FulcioURL+"/api/v2/signingCert", request, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := response.EmbeddedSCT
+	if chain == nil {
+		chain = response.DetachedSCT
+	}
+
+	if chain == nil || len(chain.Chain.Certificates) == 0 {
+		return nil, fmt.Errorf("no certificate issued")
+	}
+
+	return []byte(chain.Chain.Certificates[0]), nil
+}
+
+// upload records the signature in Rekor as a hashedrekord entry, which is
+// what `cosign verify-blob` looks for.
+func (signer *KeylessSigner) upload(payload []byte, signature Signature) error {
+	digest := sha256.Sum256(payload)
+
+	entry := map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"data": map[string]interface{}{
+				"hash": map[string]string{
+					"algorithm": "sha256",
+					"value":     hex.EncodeToString(digest[:]),
+				},
+			},
+			"signature": map[string]interface{}{
+				"content": string(signature.Signature),
+				"publicKey": map[string]string{
+					"content": base64.StdEncoding.EncodeToString(signature.Certificate),
+				},
+			},
+		},
+	}
+
+	return signer.post(signer.RekorURL+"/api/v1/log/entries", entry, nil)
+}
+
+func (signer *KeylessSigner) post(url string, body interface{}, response interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := signer.client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message := new(bytes.Buffer)
+		message.ReadFrom(resp.Body)
+		return fmt.Errorf("%s responded with %s: %s", url, resp.Status, strings.TrimSpace(message.String()))
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// tokenSubject returns the identity Fulcio expects proof of possession for:
+// the token's email if it has one, otherwise its subject. The token's
+// signature is not checked; Fulcio does that.
+func tokenSubject(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("identity token is not a JWT")
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("decoding identity token: %s", err)
+	}
+
+	var claims struct {
+		Subject string `json:"sub"`
+		Email   string `json:"email"`
+	}
+
+	err = json.Unmarshal(claimsJSON, &claims)
+	if err != nil {
+		return "", fmt.Errorf("decoding identity token: %s", err)
+	}
+
+	if claims.Email != "" {
+		return claims.Email, nil
+	}
+
+	if claims.Subject == "" {
+		return "", fmt.Errorf("identity token has no subject")
+	}
+
+	return claims.Subject, nil
+}
@@ -0,0 +1,125 @@
+package signing_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/concourse/semver-resource/signing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const leafCertificate = "-----BEGIN CERTIFICATE-----\nleaf\n-----END CERTIFICATE-----\n"
+
+var _ = Describe("KeylessSigner", func() {
+	var server *httptest.Server
+	var signer *signing.KeylessSigner
+
+	var publicKey []byte
+	var rekorEntry map[string]interface{}
+	var rekorStatus int
+
+	token := func(claims string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".c2ln"
+	}
+
+	BeforeEach(func() {
+		publicKey = nil
+		rekorEntry = nil
+		rekorStatus = http.StatusCreated
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v2/signingCert":
+				var request struct {
+					Credentials struct {
+						OIDCIdentityToken string `json:"oidcIdentityToken"`
+					} `json:"credentials"`
+					PublicKeyRequest struct {
+						PublicKey struct {
+							Content string `json:"content"`
+						} `json:"publicKey"`
+						ProofOfPossession []byte `json:"proofOfPossession"`
+					} `json:"publicKeyRequest"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+
+				publicKey = []byte(request.PublicKeyRequest.PublicKey.Content)
+
+				proof := base64.StdEncoding.EncodeToString(request.PublicKeyRequest.ProofOfPossession)
+				Expect(verify(publicKey, []byte("ci@example.com"), []byte(proof))).To(BeTrue())
+
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"signedCertificateEmbeddedSct": map[string]interface{}{
+						"chain": map[string]interface{}{
+							"certificates": []string{leafCertificate, "root"},
+						},
+					},
+				})
+
+			case "/api/v1/log/entries":
+				Expect(json.NewDecoder(r.Body).Decode(&rekorEntry)).To(Succeed())
+				w.WriteHeader(rekorStatus)
+				w.Write([]byte(`{"message": "rekor says no"}`))
+
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		signer = signing.NewKeylessSigner(server.URL, server.URL, token(`{"sub": "some-subject", "email": "ci@example.com"}`), http.DefaultClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("signs with a key certified by fulcio", func() {
+		signature, err := signer.Sign([]byte("payload"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(signature.Certificate)).To(Equal(leafCertificate))
+		Expect(verify(publicKey, []byte("payload"), signature.Signature)).To(BeTrue())
+	})
+
+	It("records the signature in rekor", func() {
+		signature, err := signer.Sign([]byte("payload"))
+		Expect(err).NotTo(HaveOccurred())
+
+		digest := sha256.Sum256([]byte("payload"))
+
+		Expect(rekorEntry).To(HaveKeyWithValue("kind", "hashedrekord"))
+
+		spec := rekorEntry["spec"].(map[string]interface{})
+		Expect(spec["data"]).To(Equal(map[string]interface{}{
+			"hash": map[string]interface{}{
+				"algorithm": "sha256",
+				"value":     hex.EncodeToString(digest[:]),
+			},
+		}))
+		Expect(spec["signature"]).To(Equal(map[string]interface{}{
+			"content": string(signature.Signature),
+			"publicKey": map[string]interface{}{
+				"content": base64.StdEncoding.EncodeToString([]byte(leafCertificate)),
+			},
+		}))
+	})
+
+	It("fails if rekor rejects the entry", func() {
+		rekorStatus = http.StatusBadRequest
+
+		_, err := signer.Sign([]byte("payload"))
+		Expect(err).To(MatchError(ContainSubstring(`400 Bad Request: {"message": "rekor says no"}`)))
+	})
+
+	It("fails if the identity token is not a JWT", func() {
+		signer.IdentityToken = "opaque"
+
+		_, err := signer.Sign([]byte("payload"))
+		Expect(err).To(MatchError("requesting signing certificate: identity token is not a JWT"))
+	})
+})
@@ -0,0 +1,162 @@
+// Package signing signs versions in the format cosign uses for blobs, so
+// that they can be checked with `cosign verify-blob`.
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// Payload is the document signed for every version written.
+type Payload struct {
+	Version   string               `json:"version"`
+	Previous  string               `json:"previous,omitempty"`
+	Bump      string               `json:"bump"`
+	Build     models.BuildMetadata `json:"build"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+func (payload Payload) Marshal() ([]byte, error) {
+	return json.MarshalIndent(payload, "", "  ")
+}
+
+// Signature is a detached signature of a payload.
+type Signature struct {
+	// Signature is the base64-encoded ASN.1 ECDSA signature of the payload's
+	// SHA-256 digest, as written by `cosign sign-blob`.
+	Signature []byte
+
+	// Certificate is the PEM-encoded certificate issued for a keyless
+	// signature, or nil when signed with a key.
+	Certificate []byte
+}
+
+type Signer interface {
+	Sign(payload []byte) (Signature, error)
+}
+
+var ErrEncryptedKey = errors.New("encrypted signing keys are not supported; provide an unencrypted PEM-encoded ECDSA key")
+
+// FromOptions returns the signer configured by options, or nil if signing is
+// not enabled.
+func FromOptions(options models.SigningOptions, client *http.Client) (Signer, error) {
+	if options.Key != "" {
+		return NewKeySigner([]byte(options.Key))
+	}
+
+	if options.Keyless {
+		identityToken := options.IdentityToken
+		if identityToken == "" {
+			identityToken = os.Getenv("SIGSTORE_ID_TOKEN")
+		}
+
+		if identityToken == "" {
+			return nil, fmt.Errorf("keyless signing requires an identity_token")
+		}
+
+		return NewKeylessSigner(
+			valueOr(options.FulcioURL, DefaultFulcioURL),
+			valueOr(options.RekorURL, DefaultRekorURL),
+			identityToken,
+			client,
+		), nil
+	}
+
+	return nil, nil
+}
+
+// KeySigner signs with a provided ECDSA key.
+type KeySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewKeySigner parses a PEM-encoded ECDSA private key, in either SEC 1 or
+// PKCS #8 form.
+func NewKeySigner(keyPEM []byte) (*KeySigner, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("signing key is not PEM-encoded")
+	}
+
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing signing key: %s", err)
+		}
+
+		return &KeySigner{key: key}, nil
+
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing signing key: %s", err)
+		}
+
+		key, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key must be an ECDSA key")
+		}
+
+		return &KeySigner{key: key}, nil
+
+	case "ENCRYPTED COSIGN PRIVATE KEY", "ENCRYPTED SIGSTORE PRIVATE KEY", "ENCRYPTED PRIVATE KEY":
+		return nil, ErrEncryptedKey
+	}
+
+	return nil, fmt.Errorf("unsupported signing key type: %s", block.Type)
+}
+
+func (signer *KeySigner) Sign(payload []byte) (Signature, error) {
+	signature, err := sign(signer.key, payload)
+	if err != nil {
+		return Signature{}, err
+	}
+
+	return Signature{Signature: encode(signature)}, nil
+}
+
+// PublicKey returns the PEM-encoded public key to verify signatures with.
+func (signer *KeySigner) PublicKey() ([]byte, error) {
+	return publicKeyPEM(&signer.key.PublicKey)
+}
+
+func sign(key *ecdsa.PrivateKey, payload []byte) ([]byte, error) {
+	digest := sha256.Sum256(payload)
+	return ecdsa.SignASN1(rand.Reader, key, digest[:])
+}
+
+func publicKeyPEM(key *ecdsa.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+func encode(signature []byte) []byte {
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(signature)))
+	base64.StdEncoding.Encode(encoded, signature)
+	return encoded
+}
+
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,111 @@
+package signing_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/signing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func verify(publicKeyPEM []byte, payload []byte, signature []byte) bool {
+	block, _ := pem.Decode(publicKeyPEM)
+	Expect(block).NotTo(BeNil())
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	Expect(err).NotTo(HaveOccurred())
+
+	decoded, err := base64.StdEncoding.DecodeString(string(signature))
+	Expect(err).NotTo(HaveOccurred())
+
+	digest := sha256.Sum256(payload)
+	return ecdsa.VerifyASN1(publicKey.(*ecdsa.PublicKey), digest[:], decoded)
+}
+
+var _ = Describe("KeySigner", func() {
+	var key *ecdsa.PrivateKey
+
+	BeforeEach(func() {
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	sec1 := func() []byte {
+		der, err := x509.MarshalECPrivateKey(key)
+		Expect(err).NotTo(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	}
+
+	It("signs payloads verifiably with a SEC 1 key", func() {
+		signer, err := signing.NewKeySigner(sec1())
+		Expect(err).NotTo(HaveOccurred())
+
+		signature, err := signer.Sign([]byte("payload"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signature.Certificate).To(BeNil())
+
+		publicKey, err := signer.PublicKey()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(verify(publicKey, []byte("payload"), signature.Signature)).To(BeTrue())
+		Expect(verify(publicKey, []byte("forged"), signature.Signature)).To(BeFalse())
+	})
+
+	It("accepts PKCS #8 keys", func() {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = signing.NewKeySigner(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects keys which are not ECDSA", func() {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		Expect(err).NotTo(HaveOccurred())
+
+		der, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = signing.NewKeySigner(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		Expect(err).To(MatchError("signing key must be an ECDSA key"))
+	})
+
+	It("rejects encrypted cosign keys", func() {
+		_, err := signing.NewKeySigner(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED COSIGN PRIVATE KEY", Bytes: []byte("x")}))
+		Expect(err).To(Equal(signing.ErrEncryptedKey))
+	})
+
+	It("rejects keys which are not PEM", func() {
+		_, err := signing.NewKeySigner([]byte("not a key"))
+		Expect(err).To(MatchError("signing key is not PEM-encoded"))
+	})
+
+	Describe("FromOptions", func() {
+		It("returns nothing when signing is not configured", func() {
+			signer, err := signing.FromOptions(models.SigningOptions{}, http.DefaultClient)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signer).To(BeNil())
+		})
+
+		It("signs with the key when one is given", func() {
+			signer, err := signing.FromOptions(models.SigningOptions{Key: string(sec1())}, http.DefaultClient)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signer).To(BeAssignableToTypeOf(&signing.KeySigner{}))
+		})
+
+		It("requires an identity token for keyless signing", func() {
+			_, err := signing.FromOptions(models.SigningOptions{Keyless: true}, http.DefaultClient)
+			Expect(err).To(MatchError("keyless signing requires an identity_token"))
+		})
+	})
+})
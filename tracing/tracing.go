@@ -0,0 +1,329 @@
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/concourse/semver-resource/models"
+)
+
+// Spans are exported to an OpenTelemetry collector using OTLP over HTTP with
+// JSON encoding. Each resource binary runs a single operation, so spans are
+// kept in memory and exported together by Flush. Until Configure is called
+// every function here is a no-op.
+
+const scopeName = "github.com/concourse/semver-resource"
+
+// Concourse build metadata, recorded as resource attributes.
+var buildAttributes = map[string]string{
+	"concourse.build.id":      "BUILD_ID",
+	"concourse.build.name":    "BUILD_NAME",
+	"concourse.job.name":      "BUILD_JOB_NAME",
+	"concourse.pipeline.name": "BUILD_PIPELINE_NAME",
+	"concourse.team.name":     "BUILD_TEAM_NAME",
+	"concourse.url":           "ATC_EXTERNAL_URL",
+}
+
+type Span struct {
+	name         string
+	traceID      string
+	spanID       string
+	parentSpanID string
+	start        time.Time
+	end          time.Time
+	attributes   map[string]string
+	err          error
+}
+
+type tracer struct {
+	endpoint string
+	headers  map[string]string
+	resource map[string]string
+	client   *http.Client
+
+	traceID  string
+	parentID string
+
+	lock  sync.Mutex
+	open  []*Span
+	ended []*Span
+}
+
+var current *tracer
+
+// Configure enables tracing when options name a collector endpoint. If the
+// step was started with a W3C TRACEPARENT, spans join that trace.
+func Configure(options models.TracingOptions, client *http.Client) {
+	if options.Endpoint == "" {
+		return
+	}
+
+	serviceName := options.ServiceName
+	if serviceName == "" {
+		serviceName = "semver-resource"
+	}
+
+	resource := map[string]string{"service.name": serviceName}
+	for attribute, env := range buildAttributes {
+		if value := os.Getenv(env); value != "" {
+			resource[attribute] = value
+		}
+	}
+
+	traceID, parentID, ok := parseTraceParent(os.Getenv("TRACEPARENT"))
+	if !ok {
+		traceID = randomID(16)
+	}
+
+	current = &tracer{
+		endpoint: strings.TrimSuffix(options.Endpoint, "/") + "/v1/traces",
+		headers:  options.Headers,
+		resource: resource,
+		client:   client,
+		traceID:  traceID,
+		parentID: parentID,
+	}
+}
+
+// Start begins a span as a child of the innermost span still open.
+func Start(name string) *Span {
+	if current == nil {
+		return nil
+	}
+
+	current.lock.Lock()
+	defer current.lock.Unlock()
+
+	parentID := current.parentID
+	if len(current.open) > 0 {
+		parentID = current.open[len(current.open)-1].spanID
+	}
+
+	span := &Span{
+		name:         name,
+		traceID:      current.traceID,
+		spanID:       randomID(8),
+		parentSpanID: parentID,
+		start:        time.Now(),
+		attributes:   map[string]string{},
+	}
+
+	current.open = append(current.open, span)
+
+	return span
+}
+
+func (span *Span) SetAttribute(key string, value string) {
+	if span == nil {
+		return
+	}
+
+	span.attributes[key] = value
+}
+
+// End finishes the span, marking it as failed if err is not nil.
+func (span *Span) End(err error) {
+	if span == nil {
+		return
+	}
+
+	current.lock.Lock()
+	defer current.lock.Unlock()
+
+	span.end = time.Now()
+	span.err = err
+
+	for i, open := range current.open {
+		if open == span {
+			current.open = append(current.open[:i], current.open[i+1:]...)
+			break
+		}
+	}
+
+	current.ended = append(current.ended, span)
+}
+
+// Fail ends every open span with err and exports them, for use right before
+// the process exits on an error.
+func Fail(err error) {
+	if current == nil {
+		return
+	}
+
+	for len(current.open) > 0 {
+		current.open[len(current.open)-1].End(err)
+	}
+
+	Flush()
+}
+
+// Flush exports every ended span to the collector.
+func Flush() error {
+	if current == nil {
+		return nil
+	}
+
+	current.lock.Lock()
+	spans := current.ended
+	current.ended = nil
+	current.lock.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(current.request(spans))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", current.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range current.headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := current.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("exporting spans: %s", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("exporting spans: collector responded with %s", response.Status)
+	}
+
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+
+	statusOK    = 1
+	statusError = 2
+)
+
+func (tracer *tracer) request(spans []*Span) otlpRequest {
+	encoded := []otlpSpan{}
+	for _, span := range spans {
+		status := otlpStatus{Code: statusOK}
+		if span.err != nil {
+			status = otlpStatus{Code: statusError, Message: span.err.Error()}
+		}
+
+		encoded = append(encoded, otlpSpan{
+			TraceID:           span.traceID,
+			SpanID:            span.spanID,
+			ParentSpanID:      span.parentSpanID,
+			Name:              span.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Attributes:        attributes(span.attributes),
+			Status:            status,
+		})
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{Attributes: attributes(tracer.resource)},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: scopeName},
+						Spans: encoded,
+					},
+				},
+			},
+		},
+	}
+}
+
+func attributes(values map[string]string) []otlpAttribute {
+	encoded := []otlpAttribute{}
+	for key, value := range values {
+		encoded = append(encoded, otlpAttribute{Key: key, Value: otlpValue{StringValue: value}})
+	}
+
+	return encoded
+}
+
+// parseTraceParent extracts the trace and parent span IDs from a W3C
+// traceparent header value, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func parseTraceParent(traceParent string) (string, string, bool) {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
+
+func randomID(size int) string {
+	id := make([]byte, size)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,114 @@
+package tracing_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/concourse/semver-resource/models"
+	"github.com/concourse/semver-resource/tracing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type exported struct {
+	ResourceSpans []struct {
+		Resource struct {
+			Attributes []struct {
+				Key   string `json:"key"`
+				Value struct {
+					StringValue string `json:"stringValue"`
+				} `json:"value"`
+			} `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []struct {
+			Spans []struct {
+				TraceID      string `json:"traceId"`
+				SpanID       string `json:"spanId"`
+				ParentSpanID string `json:"parentSpanId"`
+				Name         string `json:"name"`
+				Status       struct {
+					Code    int    `json:"code"`
+					Message string `json:"message"`
+				} `json:"status"`
+			} `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+var _ = Describe("Tracing", func() {
+	var server *httptest.Server
+	var requests []*http.Request
+	var payloads []exported
+
+	BeforeEach(func() {
+		requests = nil
+		payloads = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload exported
+			Expect(json.NewDecoder(r.Body).Decode(&payload)).To(Succeed())
+
+			requests = append(requests, r)
+			payloads = append(payloads, payload)
+		}))
+
+		os.Setenv("BUILD_PIPELINE_NAME", "some-pipeline")
+		os.Setenv("TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		tracing.Configure(models.TracingOptions{
+			Endpoint: server.URL,
+			Headers:  map[string]string{"Authorization": "Bearer some-token"},
+		}, http.DefaultClient)
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("BUILD_PIPELINE_NAME")
+		os.Unsetenv("TRACEPARENT")
+		server.Close()
+	})
+
+	It("exports nested spans joined to the build's trace", func() {
+		root := tracing.Start("out")
+		child := tracing.Start("git.push")
+		child.End(errors.New("rejected"))
+		root.End(nil)
+
+		Expect(tracing.Flush()).To(Succeed())
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].URL.Path).To(Equal("/v1/traces"))
+		Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer some-token"))
+
+		resource := payloads[0].ResourceSpans[0]
+
+		attributes := map[string]string{}
+		for _, attribute := range resource.Resource.Attributes {
+			attributes[attribute.Key] = attribute.Value.StringValue
+		}
+
+		Expect(attributes).To(HaveKeyWithValue("service.name", "semver-resource"))
+		Expect(attributes).To(HaveKeyWithValue("concourse.pipeline.name", "some-pipeline"))
+
+		spans := resource.ScopeSpans[0].Spans
+		Expect(spans).To(HaveLen(2))
+
+		Expect(spans[0].Name).To(Equal("git.push"))
+		Expect(spans[0].TraceID).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		Expect(spans[0].ParentSpanID).To(Equal(spans[1].SpanID))
+		Expect(spans[0].Status.Code).To(Equal(2))
+		Expect(spans[0].Status.Message).To(Equal("rejected"))
+
+		Expect(spans[1].Name).To(Equal("out"))
+		Expect(spans[1].ParentSpanID).To(Equal("00f067aa0ba902b7"))
+		Expect(spans[1].Status.Code).To(Equal(1))
+	})
+
+	It("does not export when no spans ended", func() {
+		Expect(tracing.Flush()).To(Succeed())
+		Expect(requests).To(BeEmpty())
+	})
+})
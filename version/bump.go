@@ -4,6 +4,7 @@ import "github.com/blang/semver"
 
 type Bump interface {
 	Apply(semver.Version) semver.Version
+	String() string
 }
 
 type IdentityBump struct{}
@@ -11,3 +12,7 @@ type IdentityBump struct{}
 func (IdentityBump) Apply(v semver.Version) semver.Version {
 	return v
 }
+
+func (IdentityBump) String() string {
+	return "none"
+}
@@ -0,0 +1,59 @@
+package version
+
+import (
+	"regexp"
+	"strings"
+)
+
+// conventionalHeader matches the first line of a Conventional Commits
+// message, e.g. "feat(parser)!: accept arrays".
+var conventionalHeader = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?: \S`)
+
+var breakingFooter = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+
+// ClassifyCommit returns the bump a commit message calls for under the
+// Conventional Commits specification: "major" for breaking changes, "minor"
+// for features, "patch" for fixes and performance improvements, and "none"
+// for anything else, including messages that don't follow the convention.
+func ClassifyCommit(message string) string {
+	header := strings.SplitN(strings.TrimSpace(message), "\n", 2)[0]
+
+	match := conventionalHeader.FindStringSubmatch(header)
+	if match == nil {
+		return "none"
+	}
+
+	if match[2] == "!" || breakingFooter.MatchString(message) {
+		return "major"
+	}
+
+	switch strings.ToLower(match[1]) {
+	case "feat":
+		return "minor"
+	case "fix", "perf":
+		return "patch"
+	}
+
+	return "none"
+}
+
+var bumpPrecedence = map[string]int{
+	"none":  0,
+	"patch": 1,
+	"minor": 2,
+	"major": 3,
+}
+
+// ClassifyCommits returns the largest bump called for by any of messages.
+func ClassifyCommits(messages []string) string {
+	bump := "none"
+
+	for _, message := range messages {
+		classified := ClassifyCommit(message)
+		if bumpPrecedence[classified] > bumpPrecedence[bump] {
+			bump = classified
+		}
+	}
+
+	return bump
+}
@@ -0,0 +1,48 @@
+package version_test
+
+import (
+	"github.com/concourse/semver-resource/version"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Conventional commits", func() {
+	Describe("ClassifyCommit", func() {
+		for _, example := range []struct {
+			description string
+			message     string
+			bump        string
+		}{
+			{"a feature", "feat: accept arrays", "minor"},
+			{"a scoped feature", "feat(parser): accept arrays", "minor"},
+			{"a fix", "fix: handle empty input", "patch"},
+			{"a performance improvement", "perf: cache lookups", "patch"},
+			{"a chore", "chore: update dependencies", "none"},
+			{"a documentation change", "docs(readme): fix typo", "none"},
+			{"a breaking change marked in the header", "refactor(api)!: drop v1", "major"},
+			{"a breaking change in a footer", "feat: accept arrays\n\nBREAKING CHANGE: objects are rejected", "major"},
+			{"a hyphenated breaking change footer", "fix: stricter parsing\n\nBREAKING-CHANGE: lenient mode is gone", "major"},
+			{"an unconventional message", "Fix the thing", "none"},
+			{"a message without a description", "feat:", "none"},
+			{"an empty message", "", "none"},
+		} {
+			example := example
+
+			It("classifies "+example.description+" as "+example.bump, func() {
+				Expect(version.ClassifyCommit(example.message)).To(Equal(example.bump))
+			})
+		}
+	})
+
+	Describe("ClassifyCommits", func() {
+		It("returns the largest bump", func() {
+			Expect(version.ClassifyCommits([]string{"fix: a", "feat: b", "chore: c"})).To(Equal("minor"))
+			Expect(version.ClassifyCommits([]string{"fix: a", "feat!: b"})).To(Equal("major"))
+		})
+
+		It("returns none without any releasable commits", func() {
+			Expect(version.ClassifyCommits([]string{"chore: a"})).To(Equal("none"))
+			Expect(version.ClassifyCommits(nil)).To(Equal("none"))
+		})
+	})
+})
@@ -8,3 +8,7 @@ func (FinalBump) Apply(v semver.Version) semver.Version {
 	v.Pre = nil
 	return v
 }
+
+func (FinalBump) String() string {
+	return "final"
+}
@@ -11,3 +11,7 @@ func (MajorBump) Apply(v semver.Version) semver.Version {
 	v.Pre = nil
 	return v
 }
+
+func (MajorBump) String() string {
+	return "major"
+}
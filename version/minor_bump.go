@@ -10,3 +10,7 @@ func (MinorBump) Apply(v semver.Version) semver.Version {
 	v.Pre = nil
 	return v
 }
+
+func (MinorBump) String() string {
+	return "minor"
+}
@@ -1,6 +1,10 @@
 package version
 
-import "github.com/blang/semver"
+import (
+	"strings"
+
+	"github.com/blang/semver"
+)
 
 type MultiBump []Bump
 
@@ -11,3 +15,16 @@ func (bumps MultiBump) Apply(v semver.Version) semver.Version {
 
 	return v
 }
+
+func (bumps MultiBump) String() string {
+	if len(bumps) == 0 {
+		return IdentityBump{}.String()
+	}
+
+	names := make([]string, len(bumps))
+	for i, bump := range bumps {
+		names[i] = bump.String()
+	}
+
+	return strings.Join(names, "+")
+}
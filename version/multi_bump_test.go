@@ -45,4 +45,12 @@ var _ = Describe("MultiBump", func() {
 			},
 		}))
 	})
+
+	It("describes the bumps in order", func() {
+		Expect(bump.String()).To(Equal("major+minor+patch+patch+patch+pre=beta+pre=beta"))
+	})
+
+	It("describes no bumps as none", func() {
+		Expect(version.MultiBump{}.String()).To(Equal("none"))
+	})
 })
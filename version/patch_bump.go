@@ -9,3 +9,7 @@ func (PatchBump) Apply(v semver.Version) semver.Version {
 	v.Pre = nil
 	return v
 }
+
+func (PatchBump) String() string {
+	return "patch"
+}
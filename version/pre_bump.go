@@ -21,3 +21,7 @@ func (bump PreBump) Apply(v semver.Version) semver.Version {
 
 	return v
 }
+
+func (bump PreBump) String() string {
+	return "pre=" + bump.Pre
+}
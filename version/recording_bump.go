@@ -0,0 +1,17 @@
+package version
+
+import "github.com/blang/semver"
+
+// RecordingBump remembers the version it was last applied to. Drivers
+// re-apply a bump whenever they lose a race, so after a successful bump this
+// is the version that was replaced.
+type RecordingBump struct {
+	Bump
+
+	Applied *semver.Version
+}
+
+func (bump *RecordingBump) Apply(v semver.Version) semver.Version {
+	bump.Applied = &v
+	return bump.Bump.Apply(v)
+}
@@ -0,0 +1,32 @@
+package version_test
+
+import (
+	"github.com/blang/semver"
+	"github.com/concourse/semver-resource/version"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RecordingBump", func() {
+	var bump *version.RecordingBump
+
+	BeforeEach(func() {
+		bump = &version.RecordingBump{Bump: version.PatchBump{}}
+	})
+
+	It("has not been applied to anything yet", func() {
+		Expect(bump.Applied).To(BeNil())
+	})
+
+	It("applies the underlying bump", func() {
+		Expect(bump.Apply(semver.Version{Major: 1, Minor: 2, Patch: 3})).To(Equal(semver.Version{Major: 1, Minor: 2, Patch: 4}))
+		Expect(bump.String()).To(Equal("patch"))
+	})
+
+	It("remembers the version it was last applied to", func() {
+		bump.Apply(semver.Version{Major: 1, Minor: 2, Patch: 3})
+		bump.Apply(semver.Version{Major: 1, Minor: 2, Patch: 5})
+
+		Expect(*bump.Applied).To(Equal(semver.Version{Major: 1, Minor: 2, Patch: 5}))
+	})
+})